@@ -0,0 +1,119 @@
+package config
+
+import (
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AuthConfig holds the token lifetimes and account rules used by the authentication service
+type AuthConfig struct {
+	AccessTokenTTL           time.Duration
+	RefreshTokenTTL          time.Duration
+	EmailVerificationTTL     time.Duration
+	PasswordResetTTL         time.Duration
+	RequireEmailVerification bool
+	AppBaseURL               string // Public URL used to build links in emails
+	PasswordResetURL         string // Front-end page that receives ?token=, empty sends the token only
+
+	// Brute-force protection for /auth/login
+	LoginFailureWindow    time.Duration // How long failed attempts are remembered
+	LoginBackoffAfter     int           // Failures per email before exponential backoff starts
+	LoginBackoffBase      time.Duration
+	LoginBackoffMax       time.Duration
+	LoginLockoutThreshold int // Failures per email before the account is locked
+	LoginLockoutDuration  time.Duration
+	LoginIPMaxFailures    int // Failures per client IP before the IP is blocked
+
+	// Two-factor authentication
+	TOTPIssuer             string // Account name shown in authenticator apps
+	TwoFactorChallengeTTL  time.Duration
+	TwoFactorMaxAttempts   int  // Codes that may be tried per login challenge
+	RequireTwoFactorAdmins bool // Admins must enrol before they can log in
+}
+
+// Auth is the authentication configuration loaded at startup
+var Auth AuthConfig
+
+// LoadAuthConfig reads the authentication settings from environment variables
+func LoadAuthConfig() {
+	Auth = AuthConfig{
+		AccessTokenTTL:  getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: getEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+
+		EmailVerificationTTL:     getEnvDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour),
+		PasswordResetTTL:         getEnvDuration("PASSWORD_RESET_TTL", time.Hour),
+		RequireEmailVerification: getEnvBool("REQUIRE_EMAIL_VERIFICATION", false),
+		AppBaseURL:               strings.TrimSuffix(getEnv("APP_BASE_URL", "http://localhost:8000"), "/"),
+		PasswordResetURL:         getEnv("PASSWORD_RESET_URL", ""),
+
+		LoginFailureWindow:    getEnvDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+		LoginBackoffAfter:     getEnvInt("LOGIN_BACKOFF_AFTER", 3),
+		LoginBackoffBase:      getEnvDuration("LOGIN_BACKOFF_BASE", time.Second),
+		LoginBackoffMax:       getEnvDuration("LOGIN_BACKOFF_MAX", 5*time.Minute),
+		LoginLockoutThreshold: getEnvInt("LOGIN_LOCKOUT_THRESHOLD", 10),
+		LoginLockoutDuration:  getEnvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		LoginIPMaxFailures:    getEnvInt("LOGIN_IP_MAX_FAILURES", 50),
+
+		TOTPIssuer:             getEnv("TOTP_ISSUER", "MentalArts Library"),
+		TwoFactorChallengeTTL:  getEnvDuration("TWO_FACTOR_CHALLENGE_TTL", 5*time.Minute),
+		TwoFactorMaxAttempts:   getEnvInt("TWO_FACTOR_MAX_ATTEMPTS", 5),
+		RequireTwoFactorAdmins: getEnvBool("REQUIRE_2FA_FOR_ADMINS", false),
+	}
+
+	if Auth.PasswordResetURL != "" {
+		if u, err := url.Parse(Auth.PasswordResetURL); err != nil || u.Scheme == "" || u.Host == "" {
+			log.Fatalf("PASSWORD_RESET_URL %q must be an absolute URL", Auth.PasswordResetURL)
+		}
+	}
+}
+
+// getEnv returns the value of an environment variable or a fallback when it is unset
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getEnvDuration parses a duration such as "15m" or "720h" from an environment variable
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := getEnv(key, "")
+	if value == "" {
+		return fallback
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid duration for %s: %v", key, err)
+	}
+	return duration
+}
+
+// getEnvBool parses a boolean such as "true" or "1" from an environment variable
+func getEnvBool(key string, fallback bool) bool {
+	value := getEnv(key, "")
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("Invalid boolean for %s: %v", key, err)
+	}
+	return parsed
+}
+
+// getEnvInt parses an integer from an environment variable
+func getEnvInt(key string, fallback int) int {
+	value := getEnv(key, "")
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("Invalid integer for %s: %v", key, err)
+	}
+	return parsed
+}
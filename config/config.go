@@ -7,10 +7,13 @@ import (
 	"mentalartsapi/internal/models"
 	"mentalartsapi/internal/utils"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Global variables for DB and Redis clients
@@ -41,8 +44,8 @@ func ConnectDatabase() {
 	// Perform database migration
 	MigrateDB()
 
-	// Seed Admin User
-	SeedAdminUser()
+	// Seed roles and permissions
+	SeedRoles()
 
 	// Connect to Redis
 	ConnectRedis()
@@ -50,11 +53,158 @@ func ConnectDatabase() {
 
 // MigrateDB runs migrations on the database
 func MigrateDB() {
-	err := DB.AutoMigrate(&models.Author{}, &models.Book{}, &models.Review{}, &models.User{})
+	removeDuplicateReviews()
+	datesConverted := migrateReviewDates()
+	datesConverted = migrateAuthorBirthDates() || datesConverted
+	ratingsMissing := DB.Migrator().HasTable(&models.Book{}) && !DB.Migrator().HasColumn(&models.Book{}, "rating_count")
+
+	err := DB.AutoMigrate(&models.Author{}, &models.Book{}, &models.Review{}, &models.ReviewVote{}, &models.ReviewReport{}, &models.ReviewReply{}, &models.Permission{}, &models.Role{}, &models.User{}, &models.RefreshToken{}, &models.PasswordResetToken{}, &models.APIKey{}, &models.RecoveryCode{}, &models.Session{})
 	if err != nil {
 		log.Fatal("Error migrating database:", err)
 	}
 	fmt.Println("Database migrated successfully!")
+
+	if ratingsMissing {
+		result := DB.Exec(models.RecomputeRatingsSQL, models.ReviewApproved)
+		if result.Error != nil {
+			log.Fatal("Error filling book rating aggregates:", result.Error)
+		}
+		log.Printf("Book rating aggregates were added and filled for %d books", result.RowsAffected)
+	}
+	if datesConverted {
+		log.Println("Review and author dates were converted to real dates")
+	}
+}
+
+// removeDuplicateReviews keeps only the newest review of each user per book so that
+// the unique index on reviews (user_id, book_id) can be created. Older copies are soft deleted.
+func removeDuplicateReviews() {
+	if !DB.Migrator().HasColumn(&models.Review{}, "user_id") {
+		return
+	}
+
+	err := DB.Exec(`UPDATE reviews SET deleted_at = NOW()
+		WHERE deleted_at IS NULL AND user_id IS NOT NULL AND id NOT IN (
+			SELECT MAX(id) FROM reviews WHERE deleted_at IS NULL AND user_id IS NOT NULL GROUP BY user_id, book_id
+		)`).Error
+	if err != nil {
+		log.Fatal("Error removing duplicate reviews:", err)
+	}
+}
+
+// legacyDateLayouts are the formats accepted for dates stored as text before they became real columns
+var legacyDateLayouts = []string{"2006-01-02", time.RFC3339, "2006-01-02 15:04:05", "02.01.2006", "02/01/2006"}
+
+// parseLegacyDate parses a date stored as text, reporting whether it could be read
+func parseLegacyDate(value string) (time.Time, bool) {
+	for _, layout := range legacyDateLayouts {
+		if parsed, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// migrateReviewDates replaces the client supplied date_posted text of reviews with the
+// posted_at timestamp. Dates that cannot be read fall back to when the row was created,
+// as does a date on the day the row was created, which keeps the time of day.
+// It reports whether anything was converted.
+func migrateReviewDates() bool {
+	if !DB.Migrator().HasColumn(&models.Review{}, "date_posted") {
+		return false
+	}
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("ALTER TABLE reviews ADD COLUMN IF NOT EXISTS posted_at timestamptz").Error; err != nil {
+			return err
+		}
+
+		var rows []struct {
+			ID         uint
+			DatePosted string
+			CreatedAt  time.Time
+		}
+		if err := tx.Raw("SELECT id, COALESCE(date_posted, '') AS date_posted, created_at FROM reviews").Scan(&rows).Error; err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			postedAt := row.CreatedAt
+			if parsed, ok := parseLegacyDate(row.DatePosted); ok && parsed.Format("2006-01-02") != row.CreatedAt.UTC().Format("2006-01-02") {
+				postedAt = parsed
+			}
+			if err := tx.Exec("UPDATE reviews SET posted_at = ? WHERE id = ?", postedAt, row.ID).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Exec("ALTER TABLE reviews ALTER COLUMN posted_at SET NOT NULL").Error; err != nil {
+			return err
+		}
+		return tx.Exec("ALTER TABLE reviews DROP COLUMN date_posted").Error
+	})
+	if err != nil {
+		log.Fatal("Error converting review dates:", err)
+	}
+	return true
+}
+
+// migrateAuthorBirthDates turns the text birth_date column of authors into a date column.
+// Birth dates that cannot be read become NULL. It reports whether anything was converted.
+func migrateAuthorBirthDates() bool {
+	if !DB.Migrator().HasColumn(&models.Author{}, "birth_date") {
+		return false
+	}
+
+	columnTypes, err := DB.Migrator().ColumnTypes(&models.Author{})
+	if err != nil {
+		log.Fatal("Error reading author columns:", err)
+	}
+	for _, column := range columnTypes {
+		if column.Name() == "birth_date" && strings.EqualFold(column.DatabaseTypeName(), "date") {
+			return false
+		}
+	}
+
+	unreadable := 0
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("ALTER TABLE authors ADD COLUMN birth_date_parsed date").Error; err != nil {
+			return err
+		}
+
+		var rows []struct {
+			ID        uint
+			BirthDate string
+		}
+		if err := tx.Raw("SELECT id, COALESCE(birth_date, '') AS birth_date FROM authors").Scan(&rows).Error; err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			parsed, ok := parseLegacyDate(row.BirthDate)
+			if !ok {
+				if row.BirthDate != "" {
+					unreadable++
+				}
+				continue
+			}
+			if err := tx.Exec("UPDATE authors SET birth_date_parsed = ? WHERE id = ?", parsed.Format("2006-01-02"), row.ID).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Exec("ALTER TABLE authors DROP COLUMN birth_date").Error; err != nil {
+			return err
+		}
+		return tx.Exec("ALTER TABLE authors RENAME COLUMN birth_date_parsed TO birth_date").Error
+	})
+	if err != nil {
+		log.Fatal("Error converting author birth dates:", err)
+	}
+	if unreadable > 0 {
+		log.Printf("%d author birth dates could not be read and were cleared", unreadable)
+	}
+	return true
 }
 
 // ConnectRedis connects to the Redis server
@@ -78,48 +228,52 @@ func ConnectRedis() {
 	fmt.Println("Redis connected successfully!")
 }
 
-// SeedAdminUser adds an admin user to the database if not already present
+// SeedAdminUser creates the first admin account on a fresh installation.
+// It only acts when no user holds the admin role and ADMIN_INITIAL_PASSWORD is set;
+// existing accounts are never touched. Use the create-admin and reset-admin
+// commands to manage admins afterwards.
 func SeedAdminUser() {
-	// Check if the admin already exists by username or email
-	var admin models.User
-	if err := DB.Where("username = ?", "admin").Or("email = ?", "admin@gmail.com").First(&admin).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			// Admin does not exist, create one
-			admin = models.User{
-				Username: "admin", // Default username for admin
-				Email:    "admin@gmail.com",
-				Password: "adminpassword", // In a real scenario, use a hashed password
-				Role:     "admin",         // Set role as admin
-			}
+	var adminRole models.Role
+	if err := DB.Where("name = ?", utils.RoleAdmin).First(&adminRole).Error; err != nil {
+		log.Fatal("Error loading admin role:", err)
+	}
 
-			// Hash the password before saving (use a utility function to hash)
-			hashedPassword, err := utils.HashPassword(admin.Password)
-			if err != nil {
-				log.Fatal("Error hashing admin password:", err)
-			}
+	var admins int64
+	if err := DB.Model(&models.User{}).Where("role_id = ?", adminRole.ID).Count(&admins).Error; err != nil {
+		log.Fatal("Error checking for existing admin user:", err)
+	}
+	if admins > 0 {
+		return
+	}
 
-			admin.Password = hashedPassword
+	password := getEnv("ADMIN_INITIAL_PASSWORD", "")
+	if password == "" {
+		log.Println("WARNING: no admin account exists. Set ADMIN_INITIAL_PASSWORD or run the create-admin command.")
+		return
+	}
 
-			// Save the admin user to the database
-			if err := DB.Create(&admin).Error; err != nil {
-				log.Fatal("Error creating admin user:", err)
-			}
-			fmt.Println("Admin user created successfully!")
-		} else {
-			log.Fatal("Error checking for existing admin user:", err)
-		}
-	} else {
-		// If admin already exists, update the password or other information
-		hashedPassword, err := utils.HashPassword("adminpassword")
-		if err != nil {
-			log.Fatal("Error hashing admin password:", err)
-		}
-		admin.Password = hashedPassword
+	username := getEnv("ADMIN_INITIAL_USERNAME", "admin")
+	email := getEnv("ADMIN_INITIAL_EMAIL", "admin@gmail.com")
+	if err := PasswordPolicy.Validate(password, username, email); err != nil {
+		log.Fatal("ADMIN_INITIAL_PASSWORD is rejected: ", err)
+	}
 
-		// Update the admin user
-		if err := DB.Save(&admin).Error; err != nil {
-			log.Fatal("Error updating admin user:", err)
-		}
-		fmt.Println("Admin user already exists! Password updated.")
+	hashedPassword, err := Passwords.Hash(password)
+	if err != nil {
+		log.Fatal("Error hashing admin password:", err)
+	}
+
+	now := time.Now()
+	admin := models.User{
+		Username:        username,
+		Email:           email,
+		Password:        hashedPassword,
+		RoleID:          &adminRole.ID,
+		EmailVerified:   true,
+		EmailVerifiedAt: &now,
+	}
+	if err := DB.Omit(clause.Associations).Create(&admin).Error; err != nil {
+		log.Fatal("Error creating admin user:", err)
 	}
+	fmt.Printf("Admin user %q created from ADMIN_INITIAL_PASSWORD\n", admin.Email)
 }
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"log"
+	"mentalartsapi/internal/utils"
+	"os"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// JWT is the key set used to sign and verify access tokens
+var JWT *utils.KeySet
+
+// LoadJWTKeys builds the JWT key set from environment variables.
+//
+// JWT_SIGNING_KEYS is a comma separated list of "kid:alg:path" entries, for example
+// "2025-01:RS256:/keys/rs.pem,2024-12:ES256:/keys/old-ec.pub". Public-only keys are
+// accepted for verification so that tokens signed by a retired key stay valid
+// until they expire. JWT_ACTIVE_KEY_ID selects the key that signs new tokens and
+// defaults to the first entry.
+//
+// When no keys are configured, JWT_SECRET is used as a single HS256 key. Without
+// either, an ephemeral ES256 key is generated and tokens do not survive a restart.
+func LoadJWTKeys() {
+	keySet := &utils.KeySet{
+		Keys:     map[string]*utils.SigningKey{},
+		Issuer:   getEnv("JWT_ISSUER", "mentalartsapi"),
+		Audience: getEnv("JWT_AUDIENCE", "mentalartsapi"),
+	}
+
+	if entries := getEnv("JWT_SIGNING_KEYS", ""); entries != "" {
+		for _, entry := range strings.Split(entries, ",") {
+			parts := strings.SplitN(strings.TrimSpace(entry), ":", 3)
+			if len(parts) != 3 {
+				log.Fatalf("Invalid JWT_SIGNING_KEYS entry %q, expected kid:alg:path", entry)
+			}
+
+			data, err := os.ReadFile(parts[2])
+			if err != nil {
+				log.Fatalf("Error reading JWT key %q: %v", parts[0], err)
+			}
+
+			key, err := utils.NewSigningKey(parts[0], parts[1], data)
+			if err != nil {
+				log.Fatal("Error loading JWT key:", err)
+			}
+			keySet.Keys[key.ID] = key
+			if keySet.ActiveKID == "" {
+				keySet.ActiveKID = key.ID
+			}
+		}
+	} else if secret := getEnv("JWT_SECRET", ""); secret != "" {
+		key, err := utils.NewSigningKey("default", "HS256", []byte(secret))
+		if err != nil {
+			log.Fatal("Error loading JWT_SECRET:", err)
+		}
+		keySet.Keys[key.ID] = key
+		keySet.ActiveKID = key.ID
+	} else {
+		log.Println("WARNING: no JWT signing keys configured, generating an ephemeral ES256 key")
+		private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			log.Fatal("Error generating JWT key:", err)
+		}
+		keySet.Keys["ephemeral"] = &utils.SigningKey{
+			ID:         "ephemeral",
+			Method:     jwt.SigningMethodES256,
+			PrivateKey: private,
+			PublicKey:  &private.PublicKey,
+		}
+		keySet.ActiveKID = "ephemeral"
+	}
+
+	if activeKID := getEnv("JWT_ACTIVE_KEY_ID", ""); activeKID != "" {
+		keySet.ActiveKID = activeKID
+	}
+	if key, ok := keySet.Keys[keySet.ActiveKID]; !ok || key.PrivateKey == nil {
+		log.Fatalf("Active JWT key %q is not configured with a private key", keySet.ActiveKID)
+	}
+
+	JWT = keySet
+}
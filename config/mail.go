@@ -0,0 +1,28 @@
+package config
+
+// MailConfig holds the settings of the outgoing mail transport
+type MailConfig struct {
+	Driver       string // smtp, file or log
+	From         string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	FileDir      string
+}
+
+// Mail is the mail configuration loaded at startup
+var Mail MailConfig
+
+// LoadMailConfig reads the mail settings from environment variables
+func LoadMailConfig() {
+	Mail = MailConfig{
+		Driver:       getEnv("MAIL_DRIVER", "log"),
+		From:         getEnv("MAIL_FROM", "no-reply@mentalarts.local"),
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnv("SMTP_PORT", "587"),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		FileDir:      getEnv("MAIL_FILE_DIR", "mail"),
+	}
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"log"
+	"mentalartsapi/internal/utils"
+	"strings"
+	"time"
+)
+
+// OIDCRoleMapping maps one value of the role claim to one of our roles
+type OIDCRoleMapping struct {
+	ClaimValue string
+	Role       string
+}
+
+// OIDCConfig holds the settings of the OpenID Connect login
+type OIDCConfig struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string // Optional, public clients rely on PKCE alone
+	RedirectURL  string
+	Scopes       []string
+	StateTTL     time.Duration // How long a started login may take
+
+	// RoleClaim names the ID token claim (string or list of strings) that RoleMapping is matched against.
+	// The first mapping that matches wins; DefaultRole is used when none does.
+	RoleClaim   string
+	RoleMapping []OIDCRoleMapping
+	DefaultRole string
+	SyncRoles   bool // Overwrite the role of existing users on every login
+}
+
+// Enabled reports whether an identity provider is configured
+func (c OIDCConfig) Enabled() bool {
+	return c.IssuerURL != "" && c.ClientID != ""
+}
+
+// OIDC is the OpenID Connect configuration loaded at startup
+var OIDC OIDCConfig
+
+// LoadOIDCConfig reads the OpenID Connect settings from environment variables.
+//
+// OIDC_ROLE_MAPPING is a comma separated list of "claim-value=role" entries, for
+// example "library-admins=admin,library-staff=librarian". Every mapped role and the
+// default role must be one of the built-in roles.
+func LoadOIDCConfig() {
+	OIDC = OIDCConfig{
+		IssuerURL:    strings.TrimSuffix(getEnv("OIDC_ISSUER_URL", ""), "/"),
+		ClientID:     getEnv("OIDC_CLIENT_ID", ""),
+		ClientSecret: getEnv("OIDC_CLIENT_SECRET", ""),
+		RedirectURL:  getEnv("OIDC_REDIRECT_URL", getEnv("APP_BASE_URL", "http://localhost:8000")+"/api/v1/auth/oidc/callback"),
+		Scopes:       strings.Fields(strings.ReplaceAll(getEnv("OIDC_SCOPES", "openid email profile"), ",", " ")),
+		StateTTL:     getEnvDuration("OIDC_STATE_TTL", 10*time.Minute),
+		RoleClaim:    getEnv("OIDC_ROLE_CLAIM", "groups"),
+		DefaultRole:  getEnv("OIDC_DEFAULT_ROLE", utils.RoleUser),
+		SyncRoles:    getEnvBool("OIDC_SYNC_ROLES", false),
+	}
+
+	if entries := getEnv("OIDC_ROLE_MAPPING", ""); entries != "" {
+		for _, entry := range strings.Split(entries, ",") {
+			parts := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+			if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				log.Fatalf("Invalid OIDC_ROLE_MAPPING entry %q, expected claim-value=role", entry)
+			}
+			if _, known := utils.RoleDescriptions[parts[1]]; !known {
+				log.Fatalf("OIDC_ROLE_MAPPING maps %q to unknown role %q", parts[0], parts[1])
+			}
+			OIDC.RoleMapping = append(OIDC.RoleMapping, OIDCRoleMapping{ClaimValue: parts[0], Role: parts[1]})
+		}
+	}
+	if _, known := utils.RoleDescriptions[OIDC.DefaultRole]; !known {
+		log.Fatalf("OIDC_DEFAULT_ROLE %q is not a known role", OIDC.DefaultRole)
+	}
+}
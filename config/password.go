@@ -0,0 +1,87 @@
+package config
+
+import (
+	"log"
+	"math"
+	"mentalartsapi/internal/utils"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcryptMaxLength is the number of bytes bcrypt looks at; anything longer is ignored
+const bcryptMaxLength = 72
+
+var (
+	// Passwords hashes and verifies user passwords
+	Passwords *utils.PasswordHasher
+	// PasswordPolicy checks new passwords
+	PasswordPolicy *utils.PasswordPolicy
+)
+
+// LoadPasswordConfig reads the password hashing parameters and policy from environment variables.
+//
+// New hashes use PASSWORD_HASH_ALGORITHM (argon2id or bcrypt). Hashes made with the
+// other algorithm or older parameters keep working and are replaced on the next login.
+func LoadPasswordConfig() {
+	algorithm := getEnv("PASSWORD_HASH_ALGORITHM", utils.HashArgon2id)
+	if algorithm != utils.HashArgon2id && algorithm != utils.HashBcrypt {
+		log.Fatalf("Invalid PASSWORD_HASH_ALGORITHM %q, expected argon2id or bcrypt", algorithm)
+	}
+
+	// Out of range values would wrap around when converted or make argon2 panic
+	memory := getEnvInt("ARGON2_MEMORY_KIB", 64*1024)
+	iterations := getEnvInt("ARGON2_ITERATIONS", 3)
+	parallelism := getEnvInt("ARGON2_PARALLELISM", 2)
+	if parallelism < 1 || parallelism > math.MaxUint8 {
+		log.Fatalf("Invalid ARGON2_PARALLELISM %d, expected 1 to %d", parallelism, math.MaxUint8)
+	}
+	if iterations < 1 || int64(iterations) > math.MaxUint32 {
+		log.Fatalf("Invalid ARGON2_ITERATIONS %d, expected at least 1", iterations)
+	}
+	if memory < 8*parallelism || int64(memory) > math.MaxUint32 {
+		log.Fatalf("Invalid ARGON2_MEMORY_KIB %d, expected at least 8 KiB per lane (%d)", memory, 8*parallelism)
+	}
+	bcryptCost := getEnvInt("BCRYPT_COST", 12)
+	if bcryptCost < bcrypt.MinCost || bcryptCost > bcrypt.MaxCost {
+		log.Fatalf("Invalid BCRYPT_COST %d, expected %d to %d", bcryptCost, bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	Passwords = &utils.PasswordHasher{
+		Algorithm: algorithm,
+		Argon2: utils.Argon2Params{
+			Memory:      uint32(memory),
+			Iterations:  uint32(iterations),
+			Parallelism: uint8(parallelism),
+			SaltLength:  16,
+			KeyLength:   32,
+		},
+		BcryptCost: bcryptCost,
+	}
+
+	maxLength := getEnvInt("PASSWORD_MAX_LENGTH", 128)
+	if algorithm == utils.HashBcrypt && maxLength > bcryptMaxLength {
+		maxLength = bcryptMaxLength
+	}
+
+	var blocklist []string
+	if path := getEnv("PASSWORD_BLOCKLIST_FILE", ""); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatal("Error reading PASSWORD_BLOCKLIST_FILE:", err)
+		}
+		blocklist = strings.Split(string(data), "\n")
+	}
+
+	PasswordPolicy = utils.NewPasswordPolicy(
+		getEnvInt("PASSWORD_MIN_LENGTH", 10),
+		maxLength,
+		getEnvInt("PASSWORD_MIN_CLASSES", 2),
+		blocklist,
+	)
+	// bcrypt's limit is in bytes, which a password with non-ASCII letters reaches in fewer characters
+	if algorithm == utils.HashBcrypt {
+		PasswordPolicy.MaxBytes = bcryptMaxLength
+	}
+}
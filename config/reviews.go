@@ -0,0 +1,89 @@
+package config
+
+import (
+	"log"
+	"mentalartsapi/internal/utils"
+	"os"
+	"strings"
+	"time"
+)
+
+// ReviewConfig holds the rules for publishing reviews
+type ReviewConfig struct {
+	PreModeration       bool // New and edited reviews wait for a moderator before they are published
+	ReportHideThreshold int  // Open reports after which a review is hidden automatically, 0 disables
+	MaxReplyDepth       int  // How deeply replies can nest; 1 allows replies to reviews only
+	Filters             ReviewFilterConfig
+}
+
+// ReviewFilterConfig holds the action of each content filter run on new and edited reviews and replies.
+// Actions are utils.FilterOff, FilterMask, FilterFlag or FilterReject.
+type ReviewFilterConfig struct {
+	WordsAction     string
+	Words           []string // Blocked in addition to the bundled word list
+	LinksAction     string   // Links and phone numbers
+	DuplicateAction string   // Text the user already posted in another review or reply
+	RateAction      string
+	RateLimit       int // Reviews, and separately replies, a user may post per RateWindow
+	RateWindow      time.Duration
+}
+
+// Reviews is the review configuration loaded at startup
+var Reviews ReviewConfig
+
+// LoadReviewConfig reads the review settings from environment variables.
+//
+// REVIEW_MODERATION is "post" (default), which publishes reviews immediately and lets
+// moderators hide them afterwards, or "pre", which queues them for approval first.
+func LoadReviewConfig() {
+	mode := getEnv("REVIEW_MODERATION", "post")
+	if mode != "pre" && mode != "post" {
+		log.Fatalf("Invalid REVIEW_MODERATION %q, expected pre or post", mode)
+	}
+
+	Reviews = ReviewConfig{
+		PreModeration:       mode == "pre",
+		ReportHideThreshold: getEnvInt("REVIEW_REPORT_HIDE_THRESHOLD", 3),
+		MaxReplyDepth:       getEnvInt("REVIEW_REPLY_MAX_DEPTH", 3),
+	}
+	if Reviews.MaxReplyDepth < 1 {
+		log.Fatal("REVIEW_REPLY_MAX_DEPTH must be at least 1")
+	}
+
+	Reviews.Filters = ReviewFilterConfig{
+		WordsAction:     getFilterAction("REVIEW_FILTER_WORDS_ACTION", utils.FilterMask, true),
+		Words:           strings.Split(getEnv("REVIEW_FILTER_WORDS", ""), ","),
+		LinksAction:     getFilterAction("REVIEW_FILTER_LINKS_ACTION", utils.FilterFlag, true),
+		DuplicateAction: getFilterAction("REVIEW_FILTER_DUPLICATE_ACTION", utils.FilterReject, false),
+		RateAction:      getFilterAction("REVIEW_FILTER_RATE_ACTION", utils.FilterReject, false),
+		RateLimit:       getEnvInt("REVIEW_FILTER_RATE_LIMIT", 5),
+		RateWindow:      getEnvDuration("REVIEW_FILTER_RATE_WINDOW", time.Hour),
+	}
+	if path := getEnv("REVIEW_FILTER_WORDS_FILE", ""); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatal("Error reading REVIEW_FILTER_WORDS_FILE:", err)
+		}
+		Reviews.Filters.Words = append(Reviews.Filters.Words, strings.Split(string(data), "\n")...)
+	}
+}
+
+// getFilterAction reads a content filter action. Only filters that find text in the review can mask it.
+func getFilterAction(key, fallback string, canMask bool) string {
+	action := getEnv(key, fallback)
+	switch action {
+	case utils.FilterOff, utils.FilterFlag, utils.FilterReject:
+		return action
+	case utils.FilterMask:
+		if canMask {
+			return action
+		}
+	}
+
+	expected := "off, flag or reject"
+	if canMask {
+		expected = "off, mask, flag or reject"
+	}
+	log.Fatalf("Invalid %s %q, expected %s", key, action, expected)
+	return ""
+}
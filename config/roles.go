@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"mentalartsapi/internal/models"
+	"mentalartsapi/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// SeedRoles makes sure every known permission and built-in role exists.
+// Built-in roles receive any default permission they are missing; permissions
+// that were granted on top of the defaults are left alone. The admin role
+// always holds every permission.
+func SeedRoles() {
+	permissions := map[string]models.Permission{}
+	for name, description := range utils.PermissionDescriptions {
+		permission := models.Permission{Name: name}
+		if err := DB.Where(models.Permission{Name: name}).Attrs(models.Permission{Description: description}).FirstOrCreate(&permission).Error; err != nil {
+			log.Fatal("Error seeding permission:", err)
+		}
+		permissions[name] = permission
+	}
+
+	for name, description := range utils.RoleDescriptions {
+		role := models.Role{Name: name}
+		if err := DB.Where(models.Role{Name: name}).Attrs(models.Role{Description: description}).FirstOrCreate(&role).Error; err != nil {
+			log.Fatal("Error seeding role:", err)
+		}
+
+		var granted []models.Permission
+		if name == utils.RoleAdmin {
+			for _, permission := range permissions {
+				granted = append(granted, permission)
+			}
+		} else {
+			for _, permissionName := range utils.DefaultRolePermissions[name] {
+				granted = append(granted, permissions[permissionName])
+			}
+		}
+
+		// Append only adds missing rows to role_permissions
+		if err := DB.Model(&role).Association("Permissions").Append(granted); err != nil {
+			log.Fatal("Error seeding role permissions:", err)
+		}
+	}
+
+	migrateLegacyUserRoles()
+	fmt.Println("Roles seeded successfully!")
+}
+
+// migrateLegacyUserRoles moves users from the old free-text "role" column to role_id
+// and gives every user without a role the default user role.
+func migrateLegacyUserRoles() {
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if tx.Migrator().HasColumn(&models.User{}, "role") {
+			if err := tx.Exec(`UPDATE users SET role_id = roles.id FROM roles
+				WHERE users.role_id IS NULL AND roles.name = LOWER(TRIM(users.role))`).Error; err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropColumn(&models.User{}, "role"); err != nil {
+				return err
+			}
+		}
+
+		return tx.Exec(`UPDATE users SET role_id = (SELECT id FROM roles WHERE name = ?)
+			WHERE role_id IS NULL`, utils.RoleUser).Error
+	})
+	if err != nil {
+		log.Fatal("Error migrating user roles:", err)
+	}
+}
@@ -0,0 +1,12 @@
+package cache
+
+import "fmt"
+
+// AuthorListKey holds the cached list of all authors. Entries cached before birth dates
+// became real dates used "authors_list"; the v2 suffix leaves those entries unread.
+const AuthorListKey = "authors_list:v2"
+
+// AuthorKey returns the cache key of a single author, under the same v2 prefix as AuthorListKey
+func AuthorKey(id uint) string {
+	return fmt.Sprintf("author:v2:%d", id)
+}
@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"fmt"
+
+	"mentalartsapi/config"
+)
+
+// BookListVersionKey holds a counter that is part of every cached book list key.
+// Any book change bumps it, which makes all cached lists unreachable at once
+// without scanning for them; they expire on their own.
+const BookListVersionKey = "books_list:version"
+
+// BookKey returns the cache key of a single book. Books cached before they carried
+// their rating summary used "book:<id>"; the v2 prefix leaves those entries unread.
+func BookKey(id uint) string {
+	return fmt.Sprintf("book:v2:%d", id)
+}
+
+// BookListKey returns the cache key of a book list page for the given list version
+// and encoded query, under the same v2 prefix as BookKey
+func BookListKey(version int64, query string) string {
+	return fmt.Sprintf("books_list:v2:%d:%s", version, query)
+}
+
+// InvalidateBooks removes every cached book and book list, for changes made outside the book service
+func InvalidateBooks() error {
+	if err := config.Redis.Incr(ctx, BookListVersionKey).Err(); err != nil {
+		return err
+	}
+
+	var keys []string
+	iter := config.Redis.Scan(ctx, 0, "book:*", 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return config.Redis.Del(ctx, keys...).Err()
+}
@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"mentalartsapi/config"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// DenyToken marks an access token as revoked until it would have expired anyway.
+func DenyToken(jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	return config.Redis.Set(ctx, fmt.Sprintf("auth:denylist:%s", jti), "1", ttl).Err()
+}
+
+// IsTokenDenied reports whether an access token has been revoked.
+func IsTokenDenied(jti string) (bool, error) {
+	n, err := config.Redis.Exists(ctx, fmt.Sprintf("auth:denylist:%s", jti)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// RevokeSessionTokens rejects every access token issued for a session.
+// Like the "valid after" marker it only has to outlive the longest-lived access token.
+func RevokeSessionTokens(sessionID uint) error {
+	return config.Redis.Set(ctx, fmt.Sprintf("auth:revoked_session:%d", sessionID), "1", config.Auth.AccessTokenTTL).Err()
+}
+
+// IsSessionRevoked reports whether the access tokens of a session have been revoked
+func IsSessionRevoked(sessionID uint) (bool, error) {
+	n, err := config.Redis.Exists(ctx, fmt.Sprintf("auth:revoked_session:%d", sessionID)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// SetTokensValidAfter revokes every access token issued to the user before t.
+// The marker is stored in milliseconds so tokens issued later in the same second stay valid,
+// and only has to outlive the longest-lived access token.
+func SetTokensValidAfter(userID uint, t time.Time) error {
+	return config.Redis.Set(ctx, fmt.Sprintf("auth:valid_after:%d", userID), t.UnixMilli(), config.Auth.AccessTokenTTL).Err()
+}
+
+// GetTokensValidAfter returns the unix time in milliseconds before which the user's tokens are revoked, or 0.
+func GetTokensValidAfter(userID uint) (int64, error) {
+	val, err := config.Redis.Get(ctx, fmt.Sprintf("auth:valid_after:%d", userID)).Result()
+	if err == redis.Nil {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(val, 10, 64)
+}
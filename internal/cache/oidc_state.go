@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"mentalartsapi/config"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// OIDCState is what we remember between redirecting to the identity provider and its callback
+type OIDCState struct {
+	CodeVerifier string `json:"code_verifier"`
+	Nonce        string `json:"nonce"`
+}
+
+// SaveOIDCState stores the PKCE verifier and nonce of a started login under its state value
+func SaveOIDCState(state string, value OIDCState, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return config.Redis.Set(ctx, fmt.Sprintf("oidc:state:%s", state), data, ttl).Err()
+}
+
+// TakeOIDCState returns and deletes a stored login state, so each state can only be used once.
+// It returns false when the state is unknown or has expired.
+func TakeOIDCState(state string) (OIDCState, bool, error) {
+	data, err := config.Redis.GetDel(ctx, fmt.Sprintf("oidc:state:%s", state)).Bytes()
+	if err == redis.Nil {
+		return OIDCState{}, false, nil
+	}
+	if err != nil {
+		return OIDCState{}, false, err
+	}
+
+	var value OIDCState
+	if err := json.Unmarshal(data, &value); err != nil {
+		return OIDCState{}, false, err
+	}
+	return value, true, nil
+}
@@ -0,0 +1,9 @@
+package cache
+
+import "fmt"
+
+// BookReviewsKey returns the cache key of the published reviews of a book. Entries cached
+// before reviews carried posted_at used "reviews_book:<id>" and are never read.
+func BookReviewsKey(bookID uint) string {
+	return fmt.Sprintf("reviews_book:v2:%d", bookID)
+}
@@ -0,0 +1,24 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"mentalartsapi/config"
+)
+
+// MarkTokenUsed records that a single-use token has been consumed.
+// It returns false when the token was already used before.
+func MarkTokenUsed(jti string, expiresAt time.Time) (bool, error) {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return false, nil
+	}
+	return config.Redis.SetNX(ctx, fmt.Sprintf("auth:used_token:%s", jti), "1", ttl).Result()
+}
+
+// AcquireCooldown returns true if the key is not cooling down and starts a new cooldown period.
+// It is used to stop clients from triggering the same email over and over.
+func AcquireCooldown(key string, ttl time.Duration) (bool, error) {
+	return config.Redis.SetNX(ctx, fmt.Sprintf("cooldown:%s", key), "1", ttl).Result()
+}
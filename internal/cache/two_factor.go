@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"mentalartsapi/config"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// LoginChallenge is what we remember about a login waiting for its second factor
+type LoginChallenge struct {
+	UserID uint   `json:"user_id"`
+	Secret string `json:"secret,omitempty"` // TOTP secret to enrol with when enrolment is required
+}
+
+// SaveLoginChallenge remembers which user passed the password step of a two-step login
+func SaveLoginChallenge(tokenHash string, challenge LoginChallenge, ttl time.Duration) error {
+	data, err := json.Marshal(challenge)
+	if err != nil {
+		return err
+	}
+	return config.Redis.Set(ctx, fmt.Sprintf("auth:2fa_challenge:%s", tokenHash), data, ttl).Err()
+}
+
+// GetLoginChallenge returns a pending login challenge.
+// It returns false when the challenge is unknown or has expired.
+func GetLoginChallenge(tokenHash string) (LoginChallenge, bool, error) {
+	data, err := config.Redis.Get(ctx, fmt.Sprintf("auth:2fa_challenge:%s", tokenHash)).Bytes()
+	if err == redis.Nil {
+		return LoginChallenge{}, false, nil
+	}
+	if err != nil {
+		return LoginChallenge{}, false, err
+	}
+
+	var challenge LoginChallenge
+	if err := json.Unmarshal(data, &challenge); err != nil {
+		return LoginChallenge{}, false, err
+	}
+	return challenge, true, nil
+}
+
+// PendingTOTPSecret returns the unexpired enrolment secret handed out to a user during login,
+// storing candidate as the new one when there is none. Repeated logins therefore keep the same
+// secret until it expires instead of invalidating the one the user may already have scanned.
+func PendingTOTPSecret(userID uint, candidate string, ttl time.Duration) (string, error) {
+	key := fmt.Sprintf("auth:2fa_enrolment:%d", userID)
+
+	stored, err := config.Redis.SetNX(ctx, key, candidate, ttl).Result()
+	if err != nil || stored {
+		return candidate, err
+	}
+
+	secret, err := config.Redis.Get(ctx, key).Result()
+	if err == redis.Nil {
+		// Expired between the two commands
+		return candidate, config.Redis.Set(ctx, key, candidate, ttl).Err()
+	}
+	return secret, err
+}
+
+// DeletePendingTOTPSecret forgets the enrolment secret of a user once it has been confirmed
+func DeletePendingTOTPSecret(userID uint) error {
+	return config.Redis.Del(ctx, fmt.Sprintf("auth:2fa_enrolment:%d", userID)).Err()
+}
+
+// CountChallengeAttempt increments and returns the number of codes tried for a challenge
+func CountChallengeAttempt(tokenHash string, ttl time.Duration) (int64, error) {
+	key := fmt.Sprintf("auth:2fa_attempts:%s", tokenHash)
+	attempts, err := config.Redis.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if attempts == 1 {
+		config.Redis.Expire(ctx, key, ttl)
+	}
+	return attempts, nil
+}
+
+// DeleteLoginChallenge ends a login challenge so it cannot be completed again
+func DeleteLoginChallenge(tokenHash string) error {
+	return config.Redis.Del(ctx,
+		fmt.Sprintf("auth:2fa_challenge:%s", tokenHash),
+		fmt.Sprintf("auth:2fa_attempts:%s", tokenHash),
+	).Err()
+}
+
+// MarkTOTPStepUsed records that a user's TOTP code for a time step has been used.
+// It returns false when the code was already used, so intercepted codes cannot be replayed.
+func MarkTOTPStepUsed(userID uint, step int64) (bool, error) {
+	return config.Redis.SetNX(ctx, fmt.Sprintf("auth:totp_used:%d:%d", userID, step), "1", 2*time.Minute).Result()
+}
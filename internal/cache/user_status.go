@@ -0,0 +1,26 @@
+package cache
+
+import (
+	"fmt"
+
+	"mentalartsapi/config"
+)
+
+// SetUserDisabled marks a user as deactivated so their tokens are rejected on every request.
+// The marker has no expiry; it is removed when the user is reactivated.
+func SetUserDisabled(userID uint, disabled bool) error {
+	key := fmt.Sprintf("auth:user_disabled:%d", userID)
+	if !disabled {
+		return config.Redis.Del(ctx, key).Err()
+	}
+	return config.Redis.Set(ctx, key, "1", 0).Err()
+}
+
+// IsUserDisabled reports whether a user has been deactivated.
+func IsUserDisabled(userID uint) (bool, error) {
+	n, err := config.Redis.Exists(ctx, fmt.Sprintf("auth:user_disabled:%d", userID)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,170 @@
+// Package cli implements the maintenance commands of the API binary.
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"mentalartsapi/config"
+	"mentalartsapi/internal/repository"
+	"mentalartsapi/internal/services"
+	"mentalartsapi/internal/utils"
+	"os"
+	"strings"
+)
+
+// Usage describes the available commands
+const Usage = `Usage: main [command] [flags]
+
+Without a command the API server is started.
+
+Commands:
+  create-admin       Create a new admin account
+  reset-admin        Set a new password for an account and make it an admin
+  recompute-ratings  Rebuild the rating aggregates of all books from their reviews
+
+Credentials are read from flags, then from ADMIN_USERNAME, ADMIN_EMAIL and
+ADMIN_PASSWORD. Use --password-stdin to pipe the password in instead of
+passing it on the command line.
+`
+
+// Run executes a command. args starts with the command name.
+func Run(args []string) error {
+	switch args[0] {
+	case "create-admin":
+		return createAdmin(args[1:])
+	case "reset-admin":
+		return resetAdmin(args[1:])
+	case "recompute-ratings":
+		return recomputeRatings(args[1:])
+	case "help", "-h", "--help":
+		fmt.Print(Usage)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, Usage)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// createAdmin handles: create-admin --username NAME --email EMAIL [--password PW | --password-stdin]
+func createAdmin(args []string) error {
+	fs := flag.NewFlagSet("create-admin", flag.ContinueOnError)
+	username := fs.String("username", os.Getenv("ADMIN_USERNAME"), "username of the new admin (env ADMIN_USERNAME)")
+	email := fs.String("email", os.Getenv("ADMIN_EMAIL"), "email address of the new admin (env ADMIN_EMAIL)")
+	password := fs.String("password", os.Getenv("ADMIN_PASSWORD"), "password (env ADMIN_PASSWORD)")
+	passwordStdin := fs.Bool("password-stdin", false, "read the password from stdin")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *username == "" || *email == "" {
+		return errors.New("--username and --email are required")
+	}
+	pw, err := readPassword(*password, *passwordStdin, os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	userService := connect()
+	user, err := userService.CreateAdmin(*username, *email, pw)
+	if err != nil {
+		return describe(err)
+	}
+
+	fmt.Printf("Admin user %q (%s) created with ID %d\n", user.Username, user.Email, user.ID)
+	return nil
+}
+
+// resetAdmin handles: reset-admin (--username NAME | --email EMAIL) [--password PW | --password-stdin] [--reset-2fa]
+func resetAdmin(args []string) error {
+	fs := flag.NewFlagSet("reset-admin", flag.ContinueOnError)
+	username := fs.String("username", os.Getenv("ADMIN_USERNAME"), "username of the account (env ADMIN_USERNAME)")
+	email := fs.String("email", os.Getenv("ADMIN_EMAIL"), "email address of the account (env ADMIN_EMAIL)")
+	password := fs.String("password", os.Getenv("ADMIN_PASSWORD"), "new password (env ADMIN_PASSWORD)")
+	passwordStdin := fs.Bool("password-stdin", false, "read the new password from stdin")
+	resetTwoFactor := fs.Bool("reset-2fa", false, "also remove the account's two-factor authentication")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	identifier := *email
+	if identifier == "" {
+		identifier = *username
+	}
+	if identifier == "" {
+		return errors.New("--username or --email is required")
+	}
+	pw, err := readPassword(*password, *passwordStdin, os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	userService := connect()
+	user, err := userService.ResetAdmin(identifier, pw)
+	if err != nil {
+		return describe(err)
+	}
+
+	// A forgotten password often comes with a lockout after failed attempts
+	if err := services.NewLoginAttemptService(config.Redis, context.Background()).Unlock(user.Email); err != nil {
+		return err
+	}
+
+	if *resetTwoFactor {
+		recoveryCodeRepo := repository.NewRecoveryCodeRepository(config.DB)
+		twoFactor := services.NewTwoFactorService(userService.Repo, *recoveryCodeRepo, userService.AuthService)
+		if err := twoFactor.ResetTwoFactor(user.ID); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Password of %q (%s) reset, account is an active admin and all sessions were revoked\n", user.Username, user.Email)
+	return nil
+}
+
+// readPassword returns the password from the flag or env value, or the first line of stdin
+func readPassword(value string, fromStdin bool, stdin io.Reader) (string, error) {
+	if fromStdin {
+		line, err := bufio.NewReader(stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		value = strings.TrimRight(line, "\r\n")
+	}
+	if value == "" {
+		return "", errors.New("a password is required: use --password, --password-stdin or ADMIN_PASSWORD")
+	}
+	return value, nil
+}
+
+// connect loads the configuration, connects to the database and Redis and returns a UserService
+func connect() *services.UserService {
+	config.LoadAuthConfig()
+	config.LoadPasswordConfig()
+	config.ConnectDatabase()
+
+	userRepo := repository.NewUserRepository(config.DB)
+	roleRepo := repository.NewRoleRepository(config.DB)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(config.DB)
+	passwordResetRepo := repository.NewPasswordResetRepository(config.DB)
+	sessionRepo := repository.NewSessionRepository(config.DB)
+	apiKeyRepo := repository.NewAPIKeyRepository(config.DB)
+
+	// The commands never send mail
+	authService := services.NewAuthService(*userRepo, *roleRepo, *refreshTokenRepo, *passwordResetRepo, *sessionRepo, *apiKeyRepo, nil)
+	return services.NewUserService(*userRepo, *roleRepo, authService)
+}
+
+// describe turns service errors into messages for the command line
+func describe(err error) error {
+	switch err {
+	case utils.ErrBadRequest:
+		return errors.New("invalid input: a username and a valid email address are required")
+	case utils.ErrNotFound:
+		return errors.New("no account with that username or email")
+	}
+	return err
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"mentalartsapi/config"
+	"mentalartsapi/internal/cache"
+	"mentalartsapi/internal/repository"
+)
+
+// recomputeRatings handles: recompute-ratings
+// The aggregates are normally kept up to date by every review change; this repairs
+// them after manual database edits or imports.
+func recomputeRatings(args []string) error {
+	fs := flag.NewFlagSet("recompute-ratings", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	config.ConnectDatabase()
+
+	updated, err := repository.NewBookRepository().RecomputeRatings()
+	if err != nil {
+		return err
+	}
+	if err := cache.InvalidateBooks(); err != nil {
+		return fmt.Errorf("ratings were rebuilt but the book cache could not be cleared: %w", err)
+	}
+
+	fmt.Printf("Rating aggregates rebuilt for %d books\n", updated)
+	return nil
+}
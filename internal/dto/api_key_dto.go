@@ -0,0 +1,28 @@
+package dto
+
+import "time"
+
+// CreateAPIKeyRequestDTO describes a new API key
+type CreateAPIKeyRequestDTO struct {
+	Name      string     `json:"name" binding:"required,min=3,max=50"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,required"` // Permissions such as "books:write"
+	ExpiresAt *time.Time `json:"expires_at"`                                    // Optional, RFC 3339
+}
+
+// APIKeyResponseDTO describes an API key without its secret
+type APIKeyResponseDTO struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreatedAPIKeyResponseDTO is returned once when a key is created. The key cannot be retrieved again.
+type CreatedAPIKeyResponseDTO struct {
+	APIKeyResponseDTO
+	Key string `json:"key"`
+}
@@ -4,7 +4,7 @@ package dto
 type RegisterRequestDTO struct {
 	Username string `json:"username" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"` // Checked against the password policy
 }
 
 // LoginRequestDTO represents the data needed to log in a user
@@ -12,3 +12,38 @@ type LoginRequestDTO struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=6"`
 }
+
+// RefreshTokenRequestDTO carries the opaque refresh token issued at login
+type RefreshTokenRequestDTO struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// TokenResponseDTO is returned whenever a new access/refresh token pair is issued
+type TokenResponseDTO struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"` // Access token lifetime in seconds
+}
+
+// VerifyEmailRequestDTO carries the token from an email verification link
+type VerifyEmailRequestDTO struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// EmailRequestDTO is used by endpoints that only need an email address
+type EmailRequestDTO struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequestDTO sets a new password using the token from a reset email
+type ResetPasswordRequestDTO struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"` // Checked against the password policy
+}
+
+// ChangePasswordRequestDTO changes the password of the logged in user
+type ChangePasswordRequestDTO struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"` // Checked against the password policy
+}
@@ -3,12 +3,12 @@ package dto
 type CreateAuthorRequestDTO struct {
 	Name      string `json:"name" binding:"required,min=3,max=30"`
 	Biography string `json:"biography" binding:"max=500"`
-	BirthDate string `json:"birth_date" binding:"required,datetime=2006-01-02"` // YYYY-MM-DD
+	BirthDate string `json:"birth_date" binding:"omitempty,datetime=2006-01-02"` // YYYY-MM-DD, empty when unknown
 }
 
 type AuthorResponseDTO struct {
-	ID        uint   `json:"id"`
-	Name      string `json:"name"`
-	Biography string `json:"biography"`
-	BirthDate string `json:"birth_date"`
+	ID        uint    `json:"id"`
+	Name      string  `json:"name"`
+	Biography string  `json:"biography"`
+	BirthDate *string `json:"birth_date"` // YYYY-MM-DD, null when unknown
 }
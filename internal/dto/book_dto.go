@@ -16,4 +16,38 @@ type BookResponseDTO struct {
 	Description     string `json:"description"`
 	AuthorID        uint   `json:"author_id"`
 	AuthorName      string `json:"author_name"`
+
+	Rating RatingSummaryDTO `json:"rating"`
+}
+
+// BookQueryDTO holds the filters and order of a book listing
+type BookQueryDTO struct {
+	AuthorID  uint    `form:"author_id"`
+	YearFrom  int     `form:"year_from"`
+	YearTo    int     `form:"year_to" binding:"omitempty,gtefield=YearFrom"`
+	ISBN      string  `form:"isbn"`
+	Title     string  `form:"title" binding:"max=50"`
+	MinRating float64 `form:"min_rating" binding:"gte=0,lte=5"`
+	Sort      string  `form:"sort" binding:"omitempty,oneof=title year created_at rating"`
+	Order     string  `form:"order" binding:"omitempty,oneof=asc desc"`
+	Cursor    string  `form:"cursor"` // next_cursor of the previous page
+
+	Page     int `form:"-"`
+	PageSize int `form:"-"`
+}
+
+// BookListResponseDTO is one page of books
+type BookListResponseDTO struct {
+	Books      []BookResponseDTO `json:"books"`
+	Page       int               `json:"page,omitempty"` // Not set when paging with a cursor
+	PageSize   int               `json:"page_size"`
+	Total      int64             `json:"total"`                 // Books matching the filters on all pages
+	NextCursor string            `json:"next_cursor,omitempty"` // Pass as cursor to get the next page, empty on the last page
+}
+
+// RatingSummaryDTO summarises the published reviews of a book
+type RatingSummaryDTO struct {
+	Count     int            `json:"count"`
+	Average   float64        `json:"average"`   // Rounded to two decimals, 0 without ratings
+	Histogram map[string]int `json:"histogram"` // Number of ratings per star, keyed "1" to "5"
 }
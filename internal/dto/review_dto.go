@@ -1,16 +1,132 @@
 package dto
 
+import "time"
+
 type CreateReviewRequestDTO struct {
-	Rating     int    `json:"rating" binding:"required,gte=1,lte=5"`
-	Comment    string `json:"comment" binding:"max=500"`
-	DatePosted string `json:"date_posted" binding:"required,datetime=2006-01-02"`
+	Rating  int    `json:"rating" binding:"required,gte=1,lte=5"`
+	Comment string `json:"comment" binding:"max=500"`
+}
+
+// ReviewConflictResponseDTO is returned when a user tries to review the same book twice
+type ReviewConflictResponseDTO struct {
+	Message  string `json:"message"`
+	ReviewID uint   `json:"review_id"`
+	Link     string `json:"link"`      // The existing review
+	MineLink string `json:"mine_link"` // PUT here to create or replace the caller's review of the book
 }
 
 type ReviewResponseDTO struct {
-	ID         uint   `json:"id"`
-	Rating     int    `json:"rating"`
-	Comment    string `json:"comment"`
-	DatePosted string `json:"date_posted"`
-	BookID     uint   `json:"book_id"`
-	BookTitle  string `json:"book_title"`
+	ID        uint       `json:"id"`
+	Rating    int        `json:"rating"`
+	Comment   string     `json:"comment"`
+	PostedAt  time.Time  `json:"posted_at"` // RFC 3339
+	EditedAt  *time.Time `json:"edited_at"` // RFC 3339, null if never edited
+	BookID    uint       `json:"book_id"`
+	BookTitle string     `json:"book_title"`
+	UserID    *uint      `json:"user_id"`  // Reviewer, null for anonymous legacy reviews
+	Username  string     `json:"username"` // Reviewer's username, empty for anonymous legacy reviews
+
+	Status           string `json:"status"`                      // pending, approved, rejected or hidden
+	ModerationReason string `json:"moderation_reason,omitempty"` // Why a moderator rejected or hid the review
+
+	HelpfulCount   int `json:"helpful_count"`
+	UnhelpfulCount int `json:"unhelpful_count"`
+
+	// Only present when requested with reply_counts or replies
+	ReplyCount *int               `json:"reply_count,omitempty"` // Published direct replies
+	Replies    []ReplyResponseDTO `json:"replies,omitempty"`     // The first direct replies, oldest first
+}
+
+// CreateReplyRequestDTO answers a review, or another reply when ParentID is set
+type CreateReplyRequestDTO struct {
+	Comment  string `json:"comment" binding:"required,max=500"`
+	ParentID *uint  `json:"parent_id"`
+}
+
+// UpdateReplyRequestDTO changes the text of a reply
+type UpdateReplyRequestDTO struct {
+	Comment string `json:"comment" binding:"required,max=500"`
+}
+
+// ReplyResponseDTO is a reply with the replies below it
+type ReplyResponseDTO struct {
+	ID               uint               `json:"id"`
+	ReviewID         uint               `json:"review_id"`
+	ParentID         *uint              `json:"parent_id"`
+	Depth            int                `json:"depth"`
+	UserID           uint               `json:"user_id"`
+	Username         string             `json:"username"`
+	Comment          string             `json:"comment"`
+	Status           string             `json:"status"`
+	ModerationReason string             `json:"moderation_reason,omitempty"`
+	CreatedAt        time.Time          `json:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at"`
+	ReplyCount       int                `json:"reply_count"`       // Visible direct replies
+	Replies          []ReplyResponseDTO `json:"replies,omitempty"` // Omitted when listing the moderation queue or embedding replies in a review
+}
+
+// ReplyListResponseDTO is one page of replies
+type ReplyListResponseDTO struct {
+	Replies  []ReplyResponseDTO `json:"replies"`
+	Page     int                `json:"page"`
+	PageSize int                `json:"page_size"`
+	Total    int64              `json:"total"`
+}
+
+// ReportReviewRequestDTO flags a review for the moderators
+type ReportReviewRequestDTO struct {
+	Category string `json:"category" binding:"required,oneof=spam abuse offensive spoiler off_topic other"`
+	Details  string `json:"details" binding:"max=1000"`
+}
+
+// ReviewReportResponseDTO is a single report
+type ReviewReportResponseDTO struct {
+	ID        uint      `json:"id"`
+	ReviewID  uint      `json:"review_id"`
+	UserID    uint      `json:"user_id"`
+	Username  string    `json:"username"`
+	Category  string    `json:"category"`
+	Details   string    `json:"details"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReportedReviewDTO is a review together with its open reports
+type ReportedReviewDTO struct {
+	Review      ReviewResponseDTO         `json:"review"`
+	ReportCount int                       `json:"report_count"`
+	Categories  map[string]int            `json:"categories"` // Open reports per category
+	Reports     []ReviewReportResponseDTO `json:"reports"`
+}
+
+// ReportedReviewListResponseDTO is one page of reported reviews
+type ReportedReviewListResponseDTO struct {
+	Reviews  []ReportedReviewDTO `json:"reviews"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"page_size"`
+	Total    int64               `json:"total"`
+}
+
+// ResolveReportsRequestDTO closes the open reports of a review.
+// dismiss keeps the review (restoring it if it was hidden automatically), hide takes it down.
+type ResolveReportsRequestDTO struct {
+	Action string `json:"action" binding:"required,oneof=dismiss hide"`
+	Reason string `json:"reason" binding:"max=500"` // Shown to the author when the review is hidden
+}
+
+// VoteReviewRequestDTO says whether the voter found a review helpful
+type VoteReviewRequestDTO struct {
+	Helpful *bool `json:"helpful" binding:"required"`
+}
+
+// ReviewListResponseDTO is one page of reviews
+type ReviewListResponseDTO struct {
+	Reviews  []ReviewResponseDTO `json:"reviews"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"page_size"`
+	Total    int64               `json:"total"`
+}
+
+// ModerateReviewRequestDTO carries the moderator's reason for a decision
+type ModerateReviewRequestDTO struct {
+	Reason string `json:"reason" binding:"max=500"` // Required to reject or hide a review
 }
@@ -0,0 +1,15 @@
+package dto
+
+import "time"
+
+// SessionResponseDTO is one device the user is signed in on
+type SessionResponseDTO struct {
+	ID          uint      `json:"id"`
+	DeviceLabel string    `json:"device_label"`
+	UserAgent   string    `json:"user_agent"`
+	IPAddress   string    `json:"ip_address"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Current     bool      `json:"current"` // The session of the access token used for this request
+}
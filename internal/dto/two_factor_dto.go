@@ -0,0 +1,48 @@
+package dto
+
+// TwoFactorStatusDTO describes the two-factor setup of the current user
+type TwoFactorStatusDTO struct {
+	Enabled                bool  `json:"enabled"`
+	Required               bool  `json:"required"` // Mandatory for the user's role
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorSetupResponseDTO is the secret to add to an authenticator app.
+// ProvisioningURI is the otpauth:// URI to render as a QR code.
+type TwoFactorSetupResponseDTO struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// TwoFactorCodeRequestDTO carries a TOTP code or, where allowed, a recovery code
+type TwoFactorCodeRequestDTO struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// RecoveryCodesResponseDTO lists freshly generated recovery codes. They are only shown once.
+type RecoveryCodesResponseDTO struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorChallengeResponseDTO is returned by /auth/login when a second factor is needed.
+// When EnrollmentRequired is set the user has to add the secret to an authenticator app first.
+type TwoFactorChallengeResponseDTO struct {
+	TwoFactorRequired  bool   `json:"two_factor_required"`
+	ChallengeToken     string `json:"challenge_token"`
+	ExpiresIn          int64  `json:"expires_in"` // Challenge lifetime in seconds
+	EnrollmentRequired bool   `json:"enrollment_required"`
+	Secret             string `json:"secret,omitempty"`
+	ProvisioningURI    string `json:"provisioning_uri,omitempty"`
+}
+
+// TwoFactorLoginRequestDTO completes a login challenge with a TOTP or recovery code
+type TwoFactorLoginRequestDTO struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+// TwoFactorLoginResponseDTO is the token pair, plus recovery codes when the login completed enrolment
+type TwoFactorLoginResponseDTO struct {
+	TokenResponseDTO
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
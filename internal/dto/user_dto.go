@@ -0,0 +1,53 @@
+package dto
+
+import "time"
+
+// UserResponseDTO is the public representation of a user account. It never includes the password.
+type UserResponseDTO struct {
+	ID               uint       `json:"id"`
+	Username         string     `json:"username"`
+	Email            string     `json:"email"`
+	Role             string     `json:"role"`
+	EmailVerified    bool       `json:"email_verified"`
+	TwoFactorEnabled bool       `json:"two_factor_enabled"`
+	IsActive         bool       `json:"is_active"`
+	DeactivatedAt    *time.Time `json:"deactivated_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// UserListResponseDTO is one page of users
+type UserListResponseDTO struct {
+	Users    []UserResponseDTO `json:"users"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"page_size"`
+	Total    int64             `json:"total"`
+}
+
+// UpdateUserRoleRequestDTO assigns a role to a user by name
+type UpdateUserRoleRequestDTO struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// ProfileResponseDTO is the current user's own view of their account
+type ProfileResponseDTO struct {
+	ID               uint      `json:"id"`
+	Username         string    `json:"username"`
+	Email            string    `json:"email"`
+	EmailVerified    bool      `json:"email_verified"`
+	TwoFactorEnabled bool      `json:"two_factor_enabled"`
+	Role             string    `json:"role"`
+	Permissions      []string  `json:"permissions"`
+	DisplayName      string    `json:"display_name"`
+	Locale           string    `json:"locale"`
+	Timezone         string    `json:"timezone"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// UpdateProfileRequestDTO changes parts of the current user's profile. Omitted fields are left unchanged.
+type UpdateProfileRequestDTO struct {
+	Username    *string `json:"username" binding:"omitempty,min=3,max=30"`
+	Email       *string `json:"email" binding:"omitempty,email"`
+	DisplayName *string `json:"display_name" binding:"omitempty,max=50"`
+	Locale      *string `json:"locale" binding:"omitempty,oneof=en tr"`
+	Timezone    *string `json:"timezone" binding:"omitempty,max=64"` // IANA name such as Europe/Istanbul
+}
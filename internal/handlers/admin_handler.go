@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"mentalartsapi/internal/dto"
+	"mentalartsapi/internal/services"
+	"mentalartsapi/internal/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler manages administrative operations on users
+type AdminHandler struct {
+	AuthService   *services.AuthService
+	UserService   *services.UserService
+	LoginAttempts *services.LoginAttemptService
+	TwoFactor     *services.TwoFactorService
+}
+
+// NewAdminHandler creates a new AdminHandler instance
+func NewAdminHandler(authService *services.AuthService, userService *services.UserService, loginAttempts *services.LoginAttemptService, twoFactor *services.TwoFactorService) *AdminHandler {
+	return &AdminHandler{AuthService: authService, UserService: userService, LoginAttempts: loginAttempts, TwoFactor: twoFactor}
+}
+
+// ListUsers lists user accounts
+//
+//	@Summary		List users
+//	@Description	Retrieves a paginated list of users, optionally filtered by a search term matching the username or email
+//	@Tags			admin
+//	@Security		Bearer
+//	@Produce		json
+//	@Param			page		query		int		false	"Page number (default 1, max 500)"
+//	@Param			page_size	query		int		false	"Page size (default 20, max 100)"
+//	@Param			search		query		string	false	"Username or email contains"
+//	@Success		200			{object}	dto.UserListResponseDTO
+//	@Failure		500			{object}	dto.ErrorResponseDTO
+//	@Router			/admin/users [get]
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	page, pageSize := parsePagination(c)
+
+	users, err := h.UserService.ListUsers(page, pageSize, strings.TrimSpace(c.Query("search")))
+	if err != nil {
+		c.Error(utils.ErrInternal)
+		return
+	}
+	c.JSON(http.StatusOK, users)
+}
+
+// GetUser retrieves a single user
+//
+//	@Summary		Get a user by ID
+//	@Description	Retrieves a user account by its ID
+//	@Tags			admin
+//	@Security		Bearer
+//	@Produce		json
+//	@Param			id	path		int	true	"User ID"
+//	@Success		200	{object}	dto.UserResponseDTO
+//	@Failure		400	{object}	dto.ErrorResponseDTO
+//	@Failure		404	{object}	dto.ErrorResponseDTO
+//	@Router			/admin/users/{id} [get]
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(utils.ErrInvalidID)
+		return
+	}
+
+	user, err := h.UserService.GetUser(uint(id))
+	if err != nil {
+		h.handleUserError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateUserRole assigns a role to a user
+//
+//	@Summary		Change a user's role
+//	@Description	Assigns a role by name. The user's tokens are revoked so the new permissions apply immediately.
+//	@Tags			admin
+//	@Security		Bearer
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int								true	"User ID"
+//	@Param			role	body		dto.UpdateUserRoleRequestDTO	true	"Role"
+//	@Success		200		{object}	dto.UserResponseDTO
+//	@Failure		400		{object}	dto.ErrorResponseDTO
+//	@Failure		404		{object}	dto.ErrorResponseDTO
+//	@Failure		500		{object}	dto.ErrorResponseDTO
+//	@Router			/admin/users/{id}/role [put]
+func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(utils.ErrInvalidID)
+		return
+	}
+
+	var req dto.UpdateUserRoleRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(utils.ErrBadRequest)
+		return
+	}
+
+	claims, _ := currentUser(c)
+	user, err := h.UserService.UpdateUserRole(claims.ID, uint(id), req.Role)
+	if err != nil {
+		h.handleUserError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// DeactivateUser blocks a user account
+//
+//	@Summary		Deactivate a user
+//	@Description	Blocks the user from logging in and rejects all of their existing tokens
+//	@Tags			admin
+//	@Security		Bearer
+//	@Produce		json
+//	@Param			id	path		int	true	"User ID"
+//	@Success		200	{object}	dto.UserResponseDTO
+//	@Failure		400	{object}	dto.ErrorResponseDTO
+//	@Failure		404	{object}	dto.ErrorResponseDTO
+//	@Failure		500	{object}	dto.ErrorResponseDTO
+//	@Router			/admin/users/{id}/deactivate [post]
+func (h *AdminHandler) DeactivateUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(utils.ErrInvalidID)
+		return
+	}
+
+	claims, _ := currentUser(c)
+	user, err := h.UserService.DeactivateUser(claims.ID, uint(id))
+	if err != nil {
+		h.handleUserError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// ReactivateUser unblocks a user account
+//
+//	@Summary		Reactivate a user
+//	@Description	Allows a deactivated user to log in again
+//	@Tags			admin
+//	@Security		Bearer
+//	@Produce		json
+//	@Param			id	path		int	true	"User ID"
+//	@Success		200	{object}	dto.UserResponseDTO
+//	@Failure		400	{object}	dto.ErrorResponseDTO
+//	@Failure		404	{object}	dto.ErrorResponseDTO
+//	@Failure		500	{object}	dto.ErrorResponseDTO
+//	@Router			/admin/users/{id}/reactivate [post]
+func (h *AdminHandler) ReactivateUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(utils.ErrInvalidID)
+		return
+	}
+
+	user, err := h.UserService.ReactivateUser(uint(id))
+	if err != nil {
+		h.handleUserError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// DeleteUser deletes a user account
+//
+//	@Summary		Delete a user
+//	@Description	Deletes a user account and revokes all of its tokens
+//	@Tags			admin
+//	@Security		Bearer
+//	@Param			id	path	int	true	"User ID"
+//	@Success		204
+//	@Failure		400	{object}	dto.ErrorResponseDTO
+//	@Failure		404	{object}	dto.ErrorResponseDTO
+//	@Failure		500	{object}	dto.ErrorResponseDTO
+//	@Router			/admin/users/{id} [delete]
+func (h *AdminHandler) DeleteUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(utils.ErrInvalidID)
+		return
+	}
+
+	claims, _ := currentUser(c)
+	if err := h.UserService.DeleteUser(claims.ID, uint(id)); err != nil {
+		h.handleUserError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// handleUserError maps user management errors to responses
+func (h *AdminHandler) handleUserError(c *gin.Context, err error) {
+	switch err {
+	case utils.ErrNotFound, utils.ErrBadRequest:
+		c.Error(err)
+	case utils.ErrCannotModifySelf:
+		c.JSON(http.StatusBadRequest, dto.ErrorResponseDTO{Message: err.Error()})
+	default:
+		c.Error(utils.ErrInternal)
+	}
+}
+
+// RevokeUserTokens force-expires all tokens of a user
+//
+//	@Summary		Revoke all tokens of a user
+//	@Description	Immediately invalidates every access token and refresh token issued to the user and revokes the user's API keys
+//	@Tags			admin
+//	@Security		Bearer
+//	@Param			id	path	int	true	"User ID"
+//	@Success		204
+//	@Failure		400	{object}	dto.ErrorResponseDTO
+//	@Failure		404	{object}	dto.ErrorResponseDTO
+//	@Failure		500	{object}	dto.ErrorResponseDTO
+//	@Router			/admin/users/{id}/revoke-tokens [post]
+func (h *AdminHandler) RevokeUserTokens(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(utils.ErrInvalidID)
+		return
+	}
+
+	if err := h.AuthService.RevokeUserTokens(uint(id)); err != nil {
+		if err == utils.ErrNotFound {
+			c.Error(utils.ErrNotFound)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponseDTO{Message: utils.ErrInternal.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// UnlockUser lifts a login lockout
+//
+//	@Summary		Unlock a user account
+//	@Description	Clears the failed login counter, backoff and lockout of a user
+//	@Tags			admin
+//	@Security		Bearer
+//	@Param			id	path	int	true	"User ID"
+//	@Success		204
+//	@Failure		400	{object}	dto.ErrorResponseDTO
+//	@Failure		404	{object}	dto.ErrorResponseDTO
+//	@Failure		500	{object}	dto.ErrorResponseDTO
+//	@Router			/admin/users/{id}/unlock [post]
+func (h *AdminHandler) UnlockUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(utils.ErrInvalidID)
+		return
+	}
+
+	user, err := h.AuthService.GetUserByID(uint(id))
+	if err != nil {
+		c.Error(utils.ErrNotFound)
+		return
+	}
+
+	if err := h.LoginAttempts.Unlock(user.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponseDTO{Message: utils.ErrInternal.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ResetTwoFactor removes the second factor of a user
+//
+//	@Summary		Reset two-factor authentication of a user
+//	@Description	Removes the TOTP secret and recovery codes of a user who lost access to both. If two-factor authentication is mandatory for the user's role, they enrol again at their next login.
+//	@Tags			admin
+//	@Security		Bearer
+//	@Param			id	path	int	true	"User ID"
+//	@Success		204
+//	@Failure		400	{object}	dto.ErrorResponseDTO
+//	@Failure		404	{object}	dto.ErrorResponseDTO
+//	@Failure		500	{object}	dto.ErrorResponseDTO
+//	@Router			/admin/users/{id}/2fa/reset [post]
+func (h *AdminHandler) ResetTwoFactor(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(utils.ErrInvalidID)
+		return
+	}
+
+	if err := h.TwoFactor.ResetTwoFactor(uint(id)); err != nil {
+		h.handleUserError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"mentalartsapi/internal/dto"
+	"mentalartsapi/internal/services"
+	"mentalartsapi/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHandler manages the current user's API keys
+type APIKeyHandler struct {
+	Service *services.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler instance
+func NewAPIKeyHandler(service *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{Service: service}
+}
+
+// CreateAPIKey creates a new API key
+//
+//	@Summary		Create an API key
+//	@Description	Creates an API key for the authenticated user. The key is only returned in this response. Scopes must be permissions the user holds.
+//	@Tags			api-keys
+//	@Security		Bearer
+//	@Accept			json
+//	@Produce		json
+//	@Param			key	body		dto.CreateAPIKeyRequestDTO	true	"API Key Data"
+//	@Success		201	{object}	dto.CreatedAPIKeyResponseDTO
+//	@Failure		400	{object}	dto.ErrorResponseDTO
+//	@Failure		403	{object}	dto.ErrorResponseDTO
+//	@Failure		500	{object}	dto.ErrorResponseDTO
+//	@Router			/me/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	claims, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
+	var req dto.CreateAPIKeyRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(utils.ErrBadRequest)
+		return
+	}
+
+	key, err := h.Service.CreateKey(claims.ID, req)
+	if err != nil {
+		switch err {
+		case utils.ErrBadRequest:
+			c.Error(err)
+		case utils.ErrScopeNotAllowed:
+			c.JSON(http.StatusForbidden, dto.ErrorResponseDTO{Message: err.Error()})
+		default:
+			c.Error(utils.ErrInternal)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// GetAPIKeys lists the current user's API keys
+//
+//	@Summary		List API keys
+//	@Description	Lists the API keys of the authenticated user without their secrets
+//	@Tags			api-keys
+//	@Security		Bearer
+//	@Produce		json
+//	@Success		200	{array}		dto.APIKeyResponseDTO
+//	@Failure		403	{object}	dto.ErrorResponseDTO
+//	@Failure		500	{object}	dto.ErrorResponseDTO
+//	@Router			/me/api-keys [get]
+func (h *APIKeyHandler) GetAPIKeys(c *gin.Context) {
+	claims, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
+	keys, err := h.Service.ListKeys(claims.ID)
+	if err != nil {
+		c.Error(utils.ErrInternal)
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// RevokeAPIKey revokes one of the current user's API keys
+//
+//	@Summary		Revoke an API key
+//	@Description	Revokes an API key of the authenticated user. Requests using it are rejected immediately.
+//	@Tags			api-keys
+//	@Security		Bearer
+//	@Param			id	path	int	true	"API Key ID"
+//	@Success		204
+//	@Failure		400	{object}	dto.ErrorResponseDTO
+//	@Failure		403	{object}	dto.ErrorResponseDTO
+//	@Failure		404	{object}	dto.ErrorResponseDTO
+//	@Failure		500	{object}	dto.ErrorResponseDTO
+//	@Router			/me/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	claims, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(utils.ErrInvalidID)
+		return
+	}
+
+	if err := h.Service.RevokeKey(claims.ID, uint(id)); err != nil {
+		if err == utils.ErrNotFound {
+			c.Error(err)
+			return
+		}
+		c.Error(utils.ErrInternal)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
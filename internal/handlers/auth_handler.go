@@ -1,24 +1,27 @@
 package handlers
 
 import (
+	"errors"
+	"log"
+	"math"
 	"mentalartsapi/internal/dto"
-	"mentalartsapi/internal/models"
 	"mentalartsapi/internal/services"
 	"mentalartsapi/internal/utils"
 	"net/http"
-	"time"
+	"strconv"
+	"strings"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 type AuthHandler struct {
-	Service *services.AuthService
+	Service       *services.AuthService
+	LoginAttempts *services.LoginAttemptService
+	TwoFactor     *services.TwoFactorService
 }
 
-func NewAuthHandler(service *services.AuthService) *AuthHandler {
-	return &AuthHandler{Service: service}
+func NewAuthHandler(service *services.AuthService, loginAttempts *services.LoginAttemptService, twoFactor *services.TwoFactorService) *AuthHandler {
+	return &AuthHandler{Service: service, LoginAttempts: loginAttempts, TwoFactor: twoFactor}
 }
 
 // RegisterUser registers a new user
@@ -30,7 +33,8 @@ func NewAuthHandler(service *services.AuthService) *AuthHandler {
 //	@Produce		json
 //	@Param			user	body		dto.RegisterRequestDTO	true	"User Registration Info"
 //	@Success		201		{object}	models.User				"User Created"
-//	@Failure		400		{object}	map[string]string		"Invalid input"
+//	@Failure		400		{object}	map[string]interface{}	"Invalid input or password rejected by the policy"
+//	@Failure		409		{object}	map[string]string		"Username or email address already in use"
 //	@Failure		500		{object}	map[string]string		"Internal server error"
 //	@Router			/auth/register [post]
 func (h *AuthHandler) RegisterUser(c *gin.Context) {
@@ -42,29 +46,48 @@ func (h *AuthHandler) RegisterUser(c *gin.Context) {
 
 	user, err := h.Service.RegisterUser(userDTO)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if respondPasswordPolicy(c, err) {
+			return
+		}
+		if err == utils.ErrEmailTaken || err == utils.ErrUsernameTaken {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		log.Println("Error registering user:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error registering user"})
 		return
 	}
 
+	// The account is created either way; the user can ask for a new link later
+	if err := h.Service.SendVerificationEmail(user); err != nil {
+		log.Println("Error sending verification email:", err)
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
-		"id":    user.ID,
-		"name":  user.Username,
-		"email": user.Email,
+		"id":             user.ID,
+		"name":           user.Username,
+		"email":          user.Email,
+		"email_verified": user.EmailVerified,
 	})
 }
 
-// LoginUser logs in an existing user and provides a JWT
+// LoginUser logs in an existing user and provides a token pair
 //
 //	@Summary		Login a user
-//	@Description	This endpoint logs in an existing user and returns a JWT token
+//	@Description	This endpoint logs in an existing user and returns a short-lived access token and a refresh token. Users with two-factor authentication get a challenge instead, to be completed at /auth/login/2fa.
 //	@Tags			Auth
 //	@Accept			json
 //	@Produce		json
-//	@Param			login	body		dto.LoginRequestDTO	true	"User Login Info"
-//	@Success		200		{object}	map[string]string	"JWT Token"
-//	@Failure		400		{object}	map[string]string	"Invalid input"
-//	@Failure		401		{object}	map[string]string	"Invalid credentials"
-//	@Failure		500		{object}	map[string]string	"Internal server error"
+//	@Param			login	body		dto.LoginRequestDTO					true	"User Login Info"
+//	@Success		200		{object}	dto.TokenResponseDTO				"Token pair"
+//	@Success		202		{object}	dto.TwoFactorChallengeResponseDTO	"Second factor required"
+//	@Failure		400		{object}	map[string]string		"Invalid input"
+//	@Failure		401		{object}	map[string]string		"Invalid credentials"
+//	@Failure		403		{object}	map[string]string		"Email address is not verified or account deactivated"
+//	@Failure		423		{object}	map[string]string		"Account temporarily locked"
+//	@Failure		429		{object}	map[string]string		"Too many failed attempts"
+//	@Failure		500		{object}	map[string]string		"Internal server error"
+//	@Header			423,429	{integer}	Retry-After				"Seconds to wait before trying again"
 //	@Router			/auth/login [post]
 func (h *AuthHandler) LoginUser(c *gin.Context) {
 	var loginDTO dto.LoginRequestDTO
@@ -73,91 +96,408 @@ func (h *AuthHandler) LoginUser(c *gin.Context) {
 		return
 	}
 
+	if err := h.LoginAttempts.Check(loginDTO.Email, c.ClientIP()); err != nil {
+		if retryErr, ok := err.(*utils.RetryAfterError); ok {
+			abortWithRetryAfter(c, retryErr)
+			return
+		}
+		// Do not lock everybody out when Redis is unavailable
+		log.Println("Error checking login attempts:", err)
+	}
+
 	user, err := h.Service.LoginUser(loginDTO)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
+		if err == utils.ErrInvalidCredentials {
+			if err := h.LoginAttempts.RecordFailure(loginDTO.Email, c.ClientIP()); err != nil {
+				log.Println("Error recording failed login:", err)
+			}
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		} else if err == utils.ErrEmailNotVerified || err == utils.ErrAccountDisabled {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		}
 		return
 	}
 
-	token, err := generateJWT(user)
+	challenge, required, err := h.TwoFactor.StartChallenge(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error starting two-factor challenge"})
+		return
+	}
+	if required {
+		// The failure history is only cleared once the second factor is accepted too
+		c.JSON(http.StatusAccepted, challenge)
+		return
+	}
+
+	if err := h.LoginAttempts.RecordSuccess(loginDTO.Email); err != nil {
+		log.Println("Error clearing failed logins:", err)
+	}
+
+	tokens, err := h.Service.IssueTokens(user, clientInfo(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"token": token,
-	})
+	c.JSON(http.StatusOK, tokens)
 }
 
-// RefreshToken refreshes a JWT token
+// LoginTwoFactor completes a login challenge with a second factor
 //
-//	@Summary		Refresh JWT Token
-//	@Description	This endpoint refreshes a JWT token using the current token
+//	@Summary		Complete a two-factor login
+//	@Description	This endpoint completes the challenge returned by /auth/login with a TOTP code or a one-time recovery code. If the challenge required enrolment, the code confirms the new secret and the response also contains the recovery codes.
 //	@Tags			Auth
 //	@Accept			json
 //	@Produce		json
-//	@Param			Authorization	header		string				true	"Bearer Token"
-//	@Success		200				{object}	map[string]string	"New JWT Token"
-//	@Failure		400				{object}	map[string]string	"Invalid token format"
-//	@Failure		401				{object}	map[string]string	"Invalid or expired token"
-//	@Failure		500				{object}	map[string]string	"Internal server error"
-//	@Router			/auth/refresh-token [post]
-func (h *AuthHandler) RefreshToken(c *gin.Context) {
-	tokenString := c.GetHeader("Authorization")
-	if tokenString == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
+//	@Param			login	body		dto.TwoFactorLoginRequestDTO	true	"Challenge Token and Code"
+//	@Success		200		{object}	dto.TwoFactorLoginResponseDTO	"Token pair"
+//	@Failure		400		{object}	map[string]string				"Invalid input"
+//	@Failure		401		{object}	map[string]string				"Invalid code or expired challenge"
+//	@Failure		403		{object}	map[string]string				"Account deactivated"
+//	@Failure		423		{object}	map[string]string				"Account temporarily locked"
+//	@Failure		429		{object}	map[string]string				"Too many failed attempts"
+//	@Failure		500		{object}	map[string]string				"Internal server error"
+//	@Header			423,429	{integer}	Retry-After						"Seconds to wait before trying again"
+//	@Router			/auth/login/2fa [post]
+func (h *AuthHandler) LoginTwoFactor(c *gin.Context) {
+	var req dto.TwoFactorLoginRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 		return
 	}
 
-	// Remove "Bearer " prefix
-	tokenString = tokenString[7:]
+	user, err := h.TwoFactor.ChallengeUser(req.ChallengeToken)
+	if err != nil {
+		if err == utils.ErrInvalidChallenge {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		} else {
+			log.Println("Error loading two-factor challenge:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not complete login"})
+		}
+		return
+	}
 
-	claims := &utils.JWTClaims{}
-	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte("your-secret-key"), nil
-	})
+	// Wrong codes count towards the same lockout as wrong passwords
+	if err := h.LoginAttempts.Check(user.Email, c.ClientIP()); err != nil {
+		if retryErr, ok := err.(*utils.RetryAfterError); ok {
+			abortWithRetryAfter(c, retryErr)
+			return
+		}
+		log.Println("Error checking login attempts:", err)
+	}
 
+	response, err := h.TwoFactor.CompleteChallenge(user, req, clientInfo(c))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		switch err {
+		case utils.ErrInvalidTwoFactor:
+			if err := h.LoginAttempts.RecordFailure(user.Email, c.ClientIP()); err != nil {
+				log.Println("Error recording failed two-factor login:", err)
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case utils.ErrInvalidChallenge:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case utils.ErrAccountDisabled:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			log.Println("Error completing two-factor login:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not complete login"})
+		}
 		return
 	}
 
-	// Token is valid, generate a new one
-	user, err := h.Service.GetUserByID(claims.ID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.LoginAttempts.RecordSuccess(user.Email); err != nil {
+		log.Println("Error clearing failed logins:", err)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RefreshToken rotates a refresh token
+//
+//	@Summary		Refresh tokens
+//	@Description	This endpoint exchanges a refresh token for a new token pair. The presented refresh token is revoked; reusing it revokes the whole session.
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			token	body		dto.RefreshTokenRequestDTO	true	"Refresh Token"
+//	@Success		200		{object}	dto.TokenResponseDTO		"New token pair"
+//	@Failure		400		{object}	map[string]string			"Invalid input"
+//	@Failure		401		{object}	map[string]string			"Invalid or expired refresh token"
+//	@Failure		500		{object}	map[string]string			"Internal server error"
+//	@Router			/auth/refresh-token [post]
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	var refreshDTO dto.RefreshTokenRequestDTO
+	if err := c.ShouldBindJSON(&refreshDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 		return
 	}
 
-	token, err := generateJWT(user)
+	tokens, err := h.Service.RefreshTokens(refreshDTO.RefreshToken, clientInfo(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
+		if err == utils.ErrInvalidRefreshToken {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error refreshing token"})
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"token": token,
-	})
+	c.JSON(http.StatusOK, tokens)
 }
 
-// Helper function to generate JWT
-func generateJWT(user models.User) (string, error) {
-	claims := &utils.JWTClaims{
-		ID:    user.ID,
-		Email: user.Email,
-		Role:  user.Role,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Hour * 24).Unix(), // Token expires in 24 hours
-			Issuer:    "your-app-name",
-		},
+// Logout revokes the session a refresh token belongs to
+//
+//	@Summary		Logout
+//	@Description	This endpoint revokes the given refresh token together with every token rotated from the same login. If an access token is sent in the Authorization header, it is revoked as well.
+//	@Tags			Auth
+//	@Accept			json
+//	@Param			token			body	dto.RefreshTokenRequestDTO	true	"Refresh Token"
+//	@Param			Authorization	header	string						false	"Bearer Token"
+//	@Success		204
+//	@Failure		400	{object}	map[string]string	"Invalid input"
+//	@Failure		401	{object}	map[string]string	"Invalid refresh token"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Router			/auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var refreshDTO dto.RefreshTokenRequestDTO
+	if err := c.ShouldBindJSON(&refreshDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := h.Service.Logout(refreshDTO.RefreshToken); err != nil {
+		if err == utils.ErrInvalidRefreshToken {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error revoking token"})
+		}
+		return
 	}
 
-	// Create token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte("your-secret-key"))
+	// Also revoke the access token if the client sent one
+	if authHeader := c.GetHeader("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+		if err := h.Service.RevokeAccessToken(strings.TrimPrefix(authHeader, "Bearer ")); err != nil {
+			log.Println("Error revoking access token on logout:", err)
+		}
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// LogoutAll revokes every refresh token of the current user
+//
+//	@Summary		Logout from all devices
+//	@Description	This endpoint revokes all refresh tokens of the authenticated user
+//	@Tags			Auth
+//	@Security		Bearer
+//	@Success		204
+//	@Failure		401	{object}	map[string]string	"Unauthorized"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Router			/auth/logout-all [post]
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	claims, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unable to retrieve user claims"})
+		return
+	}
+
+	if err := h.Service.LogoutAll(claims.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error revoking tokens"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// VerifyEmail confirms a user's email address
+//
+//	@Summary		Verify email address
+//	@Description	This endpoint consumes the single-use token from a verification email. The token can be sent as a query parameter (for links) or in the JSON body.
+//	@Tags			Auth
+//	@Accept			json
+//	@Param			token	query	string						false	"Verification Token"
+//	@Param			body	body	dto.VerifyEmailRequestDTO	false	"Verification Token"
+//	@Success		204
+//	@Failure		400	{object}	map[string]string	"Invalid or expired token"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Router			/auth/verify-email [get]
+//	@Router			/auth/verify-email [post]
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		var verifyDTO dto.VerifyEmailRequestDTO
+		if err := c.ShouldBindJSON(&verifyDTO); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		token = verifyDTO.Token
+	}
+
+	if err := h.Service.VerifyEmail(token); err != nil {
+		if err == utils.ErrInvalidActionToken {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error verifying email"})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ResendVerification sends a new verification email
+//
+//	@Summary		Resend verification email
+//	@Description	This endpoint sends a new verification link if the address belongs to an unverified account. It always answers 202 so it cannot be used to find registered addresses.
+//	@Tags			Auth
+//	@Accept			json
+//	@Param			body	body	dto.EmailRequestDTO	true	"Email Address"
+//	@Success		202
+//	@Failure		400	{object}	map[string]string	"Invalid input"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Router			/auth/resend-verification [post]
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	var emailDTO dto.EmailRequestDTO
+	if err := c.ShouldBindJSON(&emailDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := h.Service.ResendVerification(emailDTO.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error sending verification email"})
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// ForgotPassword sends a password reset email
+//
+//	@Summary		Request a password reset
+//	@Description	This endpoint mails a time-limited, single-use reset link. It always answers 202 so it cannot be used to find registered addresses.
+//	@Tags			Auth
+//	@Accept			json
+//	@Param			body	body	dto.EmailRequestDTO	true	"Email Address"
+//	@Success		202
+//	@Failure		400	{object}	map[string]string	"Invalid input"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Router			/auth/forgot-password [post]
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var emailDTO dto.EmailRequestDTO
+	if err := c.ShouldBindJSON(&emailDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := h.Service.ForgotPassword(emailDTO.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error sending password reset email"})
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// ResetPassword sets a new password with a reset token
+//
+//	@Summary		Reset password
+//	@Description	This endpoint sets a new password using the token from a reset email and revokes all existing sessions of the user
+//	@Tags			Auth
+//	@Accept			json
+//	@Param			body	body	dto.ResetPasswordRequestDTO	true	"Reset Token and New Password"
+//	@Success		204
+//	@Failure		400	{object}	map[string]interface{}	"Invalid input, expired token or password rejected by the policy"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Router			/auth/reset-password [post]
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var resetDTO dto.ResetPasswordRequestDTO
+	if err := c.ShouldBindJSON(&resetDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := h.Service.ResetPassword(resetDTO.Token, resetDTO.NewPassword); err != nil {
+		if respondPasswordPolicy(c, err) {
+			return
+		}
+		if err == utils.ErrInvalidActionToken {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error resetting password"})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ChangePassword changes the password of the current user
+//
+//	@Summary		Change password
+//	@Description	This endpoint changes the password of the authenticated user after checking the current password
+//	@Tags			Auth
+//	@Accept			json
+//	@Security		Bearer
+//	@Param			body	body	dto.ChangePasswordRequestDTO	true	"Current and New Password"
+//	@Success		204
+//	@Failure		400	{object}	map[string]interface{}	"Invalid input, incorrect current password or password rejected by the policy"
+//	@Failure		401	{object}	map[string]string	"Unauthorized"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Router			/auth/change-password [post]
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	claims, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unable to retrieve user claims"})
+		return
+	}
+
+	var changeDTO dto.ChangePasswordRequestDTO
+	if err := c.ShouldBindJSON(&changeDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := h.Service.ChangePassword(claims.ID, changeDTO.CurrentPassword, changeDTO.NewPassword); err != nil {
+		if respondPasswordPolicy(c, err) {
+			return
+		}
+		if err == utils.ErrIncorrectPassword {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error changing password"})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetJWKS publishes the public keys used to sign access tokens.
+// It is served at /.well-known/jwks.json, outside of the /api/v1 base path.
+func (h *AuthHandler) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.Service.JWKS())
+}
+
+// abortWithRetryAfter answers 423 for locked accounts and 429 otherwise, with a Retry-After header
+func abortWithRetryAfter(c *gin.Context, err *utils.RetryAfterError) {
+	seconds := int(math.Ceil(err.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+
+	status := http.StatusTooManyRequests
+	if err.Err == utils.ErrAccountLocked {
+		status = http.StatusLocked
+	}
+	c.AbortWithStatusJSON(status, gin.H{"error": err.Error(), "retry_after": seconds})
+}
+
+// respondPasswordPolicy answers with 400 and the list of problems when err is a password policy violation
+func respondPasswordPolicy(c *gin.Context, err error) bool {
+	var policyErr *utils.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "Password does not meet the requirements", "details": policyErr.Problems})
+	return true
 }
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mentalartsapi/config"
+	"mentalartsapi/internal/services"
+	"mentalartsapi/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// postLogin sends a login request from 203.0.113.9 to a handler without a database.
+// Only requests stopped by the attempt limits are answered before the database is used.
+func postLogin(t *testing.T, email string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	handler := &AuthHandler{LoginAttempts: services.NewLoginAttemptService(config.Redis, context.Background())}
+	router := gin.New()
+	router.POST("/auth/login", handler.LoginUser)
+
+	body := `{"email":"` + email + `","password":"secret-password"}`
+	request := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	request.RemoteAddr = "203.0.113.9:4711"
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestLoginUserLockedAccount(t *testing.T) {
+	testutil.UseFakeRedis(t)
+	config.Redis.Set(context.Background(), "login:lock:email:jane@example.com", "1", 90*time.Second+300*time.Millisecond)
+
+	recorder := postLogin(t, "Jane@example.com")
+
+	if recorder.Code != http.StatusLocked {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusLocked, recorder.Body)
+	}
+	// Partial seconds are rounded up so clients never retry too early
+	if got := recorder.Header().Get("Retry-After"); got != "91" {
+		t.Errorf("Retry-After = %q, want 91", got)
+	}
+	var body struct {
+		Error      string `json:"error"`
+		RetryAfter int    `json:"retry_after"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.RetryAfter != 91 || body.Error == "" {
+		t.Errorf("body = %+v, want the error and retry_after 91", body)
+	}
+}
+
+func TestLoginUserBackoff(t *testing.T) {
+	testutil.UseFakeRedis(t)
+	config.Redis.Set(context.Background(), "login:backoff:ip:203.0.113.9", "1", 2*time.Second)
+
+	recorder := postLogin(t, "someone@example.com")
+
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusTooManyRequests, recorder.Body)
+	}
+	if got := recorder.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want 2", got)
+	}
+}
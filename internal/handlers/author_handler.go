@@ -67,7 +67,7 @@ func (h *AuthorHandler) GetAuthor(c *gin.Context) {
 // CreateAuthor, yeni bir yazar oluşturur.
 //
 //	@Summary		Create a new author
-//	@Description	Creates a new author using the provided details
+//	@Description	Creates a new author using the provided details. The birth date is optional; when given it is a YYYY-MM-DD date that must not lie in the future.
 //	@Tags			authors
 //	@Accept			json
 //	@Produce		json
@@ -85,7 +85,11 @@ func (h *AuthorHandler) CreateAuthor(c *gin.Context) {
 
 	author, err := h.Service.CreateAuthor(req)
 	if err != nil {
-		c.Error(utils.ErrInternal)
+		if err == utils.ErrBadRequest {
+			c.Error(err)
+		} else {
+			c.Error(utils.ErrInternal)
+		}
 		return
 	}
 
@@ -95,7 +99,7 @@ func (h *AuthorHandler) CreateAuthor(c *gin.Context) {
 // UpdateAuthor, bir yazarı günceller.
 //
 //	@Summary		Update an author
-//	@Description	Updates an existing author by ID
+//	@Description	Updates an existing author by ID. Leaving out the birth date clears it.
 //	@Tags			authors
 //	@Accept			json
 //	@Produce		json
@@ -120,7 +124,11 @@ func (h *AuthorHandler) UpdateAuthor(c *gin.Context) {
 
 	updatedAuthor, err := h.Service.UpdateAuthor(uint(id), req)
 	if err != nil {
-		c.Error(utils.ErrInternal)
+		if err == utils.ErrBadRequest {
+			c.Error(err)
+		} else {
+			c.Error(utils.ErrInternal)
+		}
 		return
 	}
 
@@ -20,19 +20,42 @@ func NewBookHandler(service *services.BookService) *BookHandler {
 	return &BookHandler{Service: service}
 }
 
-// GetBooks, tüm kitapları getirir.
+// GetBooks, kitapları filtreleyip sayfa sayfa getirir.
 //
-//	@Summary		Get all books
-//	@Description	Retrieves a list of all books
+//	@Summary		List books
+//	@Description	Retrieves one page of books matching the filters. Pages are selected with page and page_size, or with the next_cursor of the previous page, which stays stable while books are added. A cursor only works with the sort and order it was made for.
 //	@Tags			books
 //	@Produce		json
-//	@Success		200	{array}		dto.BookResponseDTO
-//	@Failure		500	{object}	dto.ErrorResponseDTO
+//	@Param			author_id	query		int		false	"Only books by this author"
+//	@Param			year_from	query		int		false	"Published in or after this year"
+//	@Param			year_to		query		int		false	"Published in or before this year"
+//	@Param			isbn		query		string	false	"ISBN, hyphens are ignored"
+//	@Param			title		query		string	false	"Part of the title, case-insensitive"
+//	@Param			min_rating	query		number	false	"Minimum average rating (0-5)"
+//	@Param			sort		query		string	false	"Order by (default created_at)"	Enums(title, year, created_at, rating)
+//	@Param			order		query		string	false	"asc or desc (default desc for created_at and rating, asc otherwise)"	Enums(asc, desc)
+//	@Param			page		query		int		false	"Page number (default 1, max 500), ignored with a cursor; use the cursor to page further"
+//	@Param			page_size	query		int		false	"Page size (default 20, max 100)"
+//	@Param			cursor		query		string	false	"next_cursor of the previous page"
+//	@Success		200			{object}	dto.BookListResponseDTO
+//	@Failure		400			{object}	dto.ErrorResponseDTO
+//	@Failure		500			{object}	dto.ErrorResponseDTO
 //	@Router			/books [get]
 func (h *BookHandler) GetBooks(c *gin.Context) {
-	books, err := h.Service.GetBooks()
+	var query dto.BookQueryDTO
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(utils.ErrBadRequest)
+		return
+	}
+	query.Page, query.PageSize = parsePagination(c)
+
+	books, err := h.Service.GetBooks(query)
 	if err != nil {
-		c.Error(utils.ErrInternal)
+		if err == utils.ErrBadRequest {
+			c.Error(err)
+		} else {
+			c.Error(utils.ErrInternal)
+		}
 		return
 	}
 	c.JSON(http.StatusOK, books)
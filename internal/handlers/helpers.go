@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"mentalartsapi/internal/services"
+	"mentalartsapi/internal/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	maxPage         = 500 // Keeps offsets small; book listings page deeper with cursors
+)
+
+// currentUser returns the claims that JWTAuthMiddleware stored for the request
+func currentUser(c *gin.Context) (*utils.JWTClaims, bool) {
+	userClaims, _ := c.Get("user")
+	claims, ok := userClaims.(*utils.JWTClaims)
+	return claims, ok
+}
+
+// clientInfo describes the device a request came from. Clients may name
+// themselves with the X-Device-Name header, otherwise the user agent is used.
+func clientInfo(c *gin.Context) services.ClientInfo {
+	return services.ClientInfo{
+		IPAddress:   c.ClientIP(),
+		UserAgent:   c.Request.UserAgent(),
+		DeviceLabel: c.GetHeader("X-Device-Name"),
+	}
+}
+
+// parsePagination reads the page and page_size query parameters with sane defaults and limits
+func parsePagination(c *gin.Context) (int, int) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	if page > maxPage {
+		page = maxPage
+	}
+
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultPageSize)))
+	if err != nil || pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	return page, pageSize
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"mentalartsapi/internal/dto"
+	"mentalartsapi/internal/services"
+	"mentalartsapi/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MeHandler manages the current user's own account
+type MeHandler struct {
+	UserService *services.UserService
+}
+
+// NewMeHandler creates a new MeHandler instance
+func NewMeHandler(userService *services.UserService) *MeHandler {
+	return &MeHandler{UserService: userService}
+}
+
+// GetProfile returns the current user's profile
+//
+//	@Summary		Get current user
+//	@Description	Returns the profile, role and permissions of the authenticated user
+//	@Tags			me
+//	@Security		Bearer
+//	@Produce		json
+//	@Success		200	{object}	dto.ProfileResponseDTO
+//	@Failure		401	{object}	dto.ErrorResponseDTO
+//	@Failure		404	{object}	dto.ErrorResponseDTO
+//	@Router			/me [get]
+func (h *MeHandler) GetProfile(c *gin.Context) {
+	claims, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
+	profile, err := h.UserService.GetProfile(claims.ID)
+	if err != nil {
+		h.handleProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+// UpdateProfile changes the current user's profile
+//
+//	@Summary		Update current user
+//	@Description	Updates the username, email and display preferences of the authenticated user. Omitted fields are left unchanged. Changing the email address requires verifying it again.
+//	@Tags			me
+//	@Security		Bearer
+//	@Accept			json
+//	@Produce		json
+//	@Param			profile	body		dto.UpdateProfileRequestDTO	true	"Profile changes"
+//	@Success		200		{object}	dto.ProfileResponseDTO
+//	@Failure		400		{object}	dto.ErrorResponseDTO
+//	@Failure		401		{object}	dto.ErrorResponseDTO
+//	@Failure		409		{object}	dto.ErrorResponseDTO
+//	@Failure		500		{object}	dto.ErrorResponseDTO
+//	@Router			/me [patch]
+func (h *MeHandler) UpdateProfile(c *gin.Context) {
+	claims, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
+	var req dto.UpdateProfileRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(utils.ErrBadRequest)
+		return
+	}
+
+	profile, err := h.UserService.UpdateProfile(claims.ID, req)
+	if err != nil {
+		h.handleProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+// handleProfileError maps profile errors to responses
+func (h *MeHandler) handleProfileError(c *gin.Context, err error) {
+	switch err {
+	case utils.ErrNotFound, utils.ErrBadRequest:
+		c.Error(err)
+	case utils.ErrEmailTaken, utils.ErrUsernameTaken:
+		c.JSON(http.StatusConflict, dto.ErrorResponseDTO{Message: err.Error()})
+	default:
+		c.Error(utils.ErrInternal)
+	}
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"log"
+	"mentalartsapi/internal/services"
+	"mentalartsapi/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OIDCHandler handles login through the OpenID Connect provider
+type OIDCHandler struct {
+	Service   *services.OIDCService
+	TwoFactor *services.TwoFactorService
+}
+
+// NewOIDCHandler creates a new OIDCHandler instance
+func NewOIDCHandler(service *services.OIDCService, twoFactor *services.TwoFactorService) *OIDCHandler {
+	return &OIDCHandler{Service: service, TwoFactor: twoFactor}
+}
+
+// StartLogin sends the user to the identity provider
+//
+//	@Summary		Start an OpenID Connect login
+//	@Description	This endpoint redirects to the identity provider using the authorization code flow with PKCE. With redirect=false the provider URL is returned as JSON instead, for clients that open it themselves.
+//	@Tags			Auth
+//	@Produce		json
+//	@Param			redirect	query		bool				false	"Redirect to the provider (default true)"
+//	@Success		200			{object}	map[string]string	"Provider URL"
+//	@Success		302
+//	@Failure		404	{object}	map[string]string	"OpenID Connect login is not configured"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Router			/auth/oidc/login [get]
+func (h *OIDCHandler) StartLogin(c *gin.Context) {
+	authURL, err := h.Service.StartLogin()
+	if err != nil {
+		if err == utils.ErrOIDCDisabled {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		log.Println("Error starting OIDC login:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to reach the identity provider"})
+		return
+	}
+
+	if c.Query("redirect") == "false" {
+		c.JSON(http.StatusOK, gin.H{"authorization_url": authURL})
+		return
+	}
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback finishes a login started with StartLogin
+//
+//	@Summary		OpenID Connect callback
+//	@Description	The identity provider redirects here after the user signed in. The code is exchanged, the ID token is verified and the matching user is linked or created. Returns our own token pair, or a two-factor challenge like /auth/login.
+//	@Tags			Auth
+//	@Produce		json
+//	@Param			code	query		string								true	"Authorization Code"
+//	@Param			state	query		string								true	"State"
+//	@Success		200		{object}	dto.TokenResponseDTO				"Token pair"
+//	@Success		202		{object}	dto.TwoFactorChallengeResponseDTO	"Second factor required"
+//	@Failure		400		{object}	map[string]string		"Invalid or expired state"
+//	@Failure		401		{object}	map[string]string		"Login with the identity provider failed"
+//	@Failure		403		{object}	map[string]string		"Account deactivated"
+//	@Failure		404		{object}	map[string]string		"OpenID Connect login is not configured"
+//	@Failure		409		{object}	map[string]string		"Email address belongs to another account"
+//	@Failure		500		{object}	map[string]string		"Internal server error"
+//	@Router			/auth/oidc/callback [get]
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	// The provider reports cancelled or denied logins through the error parameter
+	if providerError := c.Query("error"); providerError != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": utils.ErrOIDCLoginFailed.Error(), "provider_error": providerError})
+		return
+	}
+
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing code or state"})
+		return
+	}
+
+	user, err := h.Service.CompleteLogin(code, state)
+	if err != nil {
+		switch err {
+		case utils.ErrOIDCDisabled:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case utils.ErrInvalidOIDCState:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case utils.ErrOIDCLoginFailed:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case utils.ErrAccountDisabled:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case utils.ErrEmailTaken:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			log.Println("Error completing OIDC login:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not complete login"})
+		}
+		return
+	}
+
+	challenge, required, err := h.TwoFactor.StartChallenge(user)
+	if err != nil {
+		log.Println("Error starting two-factor challenge:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not complete login"})
+		return
+	}
+	if required {
+		c.JSON(http.StatusAccepted, challenge)
+		return
+	}
+
+	tokens, err := h.Service.AuthService.IssueTokens(user, clientInfo(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"mentalartsapi/internal/dto"
+	"mentalartsapi/internal/models"
 	"mentalartsapi/internal/services"
 	"mentalartsapi/internal/utils"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,54 +17,208 @@ import (
 // ReviewHandler manages review-related operations
 type ReviewHandler struct {
 	Service *services.ReviewService
+	Replies *services.ReviewReplyService // Embeds replies in review listings
 }
 
+// maxEmbeddedReplies limits the replies query parameter of review listings
+const maxEmbeddedReplies = 10
+
 // NewReviewHandler creates a new ReviewHandler instance
-func NewReviewHandler(service *services.ReviewService) *ReviewHandler {
-	return &ReviewHandler{Service: service}
+func NewReviewHandler(service *services.ReviewService, replies *services.ReviewReplyService) *ReviewHandler {
+	return &ReviewHandler{Service: service, Replies: replies}
 }
 
 // GetReviewsForBook retrieves all reviews for a specific book
 //
 //	@Summary		Get reviews for a book
-//	@Description	Retrieves a list of reviews for a specific book by its ID
+//	@Description	Retrieves the published reviews of a book by its ID, plus the caller's own review while it awaits moderation. Set reply_counts to include the number of published replies, or replies to also embed the first direct replies of each review.
 //	@Tags			reviews
 //	@Produce		json
-//	@Param			id	path		int	true	"Book ID"
-//	@Success		200	{array}		dto.ReviewResponseDTO
-//	@Failure		400	{object}	dto.ErrorResponseDTO
-//	@Failure		500	{object}	dto.ErrorResponseDTO
+//	@Security		Bearer
+//	@Param			id				path		int		true	"Book ID"
+//	@Param			sort			query		string	false	"Order: newest (default) or helpful"	Enums(newest, helpful)
+//	@Param			reply_counts	query		bool	false	"Include the number of published direct replies"
+//	@Param			replies			query		int		false	"Embed up to this many direct replies per review (max 10), implies reply_counts"
+//	@Success		200				{array}		dto.ReviewResponseDTO
+//	@Failure		400				{object}	dto.ErrorResponseDTO
+//	@Failure		401				{object}	dto.ErrorResponseDTO
+//	@Failure		500				{object}	dto.ErrorResponseDTO
 //	@Router			/books/{id}/reviews [get]
 func (h *ReviewHandler) GetReviewsForBook(c *gin.Context) {
+	actor, ok := reviewActor(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
 	bookID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.Error(utils.ErrInvalidID)
 		return
 	}
 
-	reviews, err := h.Service.GetReviews(uint(bookID))
+	order := c.DefaultQuery("sort", services.ReviewSortNewest)
+	if order != services.ReviewSortNewest && order != services.ReviewSortHelpful {
+		c.Error(utils.ErrBadRequest)
+		return
+	}
+
+	withCounts, err := strconv.ParseBool(c.DefaultQuery("reply_counts", "false"))
+	if err != nil {
+		c.Error(utils.ErrBadRequest)
+		return
+	}
+	replyLimit, err := strconv.Atoi(c.DefaultQuery("replies", "0"))
+	if err != nil || replyLimit < 0 || replyLimit > maxEmbeddedReplies {
+		c.Error(utils.ErrBadRequest)
+		return
+	}
+
+	reviews, err := h.Service.GetReviews(uint(bookID), actor, order)
 	if err != nil {
 		c.Error(utils.ErrInternal)
 		return
 	}
 
+	if withCounts || replyLimit > 0 {
+		if err := h.Replies.EmbedReplies(reviews, replyLimit); err != nil {
+			c.Error(utils.ErrInternal)
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, reviews)
 }
 
+// GetMyReviews retrieves the reviews written by the current user
+//
+//	@Summary		Get my reviews
+//	@Description	Retrieves the reviews written by the authenticated user, newest first, including those awaiting moderation
+//	@Tags			me
+//	@Security		Bearer
+//	@Produce		json
+//	@Success		200	{array}		dto.ReviewResponseDTO
+//	@Failure		401	{object}	dto.ErrorResponseDTO
+//	@Failure		500	{object}	dto.ErrorResponseDTO
+//	@Router			/me/reviews [get]
+func (h *ReviewHandler) GetMyReviews(c *gin.Context) {
+	actor, ok := reviewActor(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
+	reviews, err := h.Service.GetUserReviews(actor.UserID, actor)
+	if err != nil {
+		c.Error(utils.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, reviews)
+}
+
+// GetUserReviews retrieves the reviews written by a user
+//
+//	@Summary		Get reviews of a user
+//	@Description	Retrieves the published reviews written by a user, newest first. Moderators also see unpublished ones.
+//	@Tags			reviews
+//	@Security		Bearer
+//	@Produce		json
+//	@Param			id	path		int	true	"User ID"
+//	@Success		200	{array}		dto.ReviewResponseDTO
+//	@Failure		400	{object}	dto.ErrorResponseDTO
+//	@Failure		401	{object}	dto.ErrorResponseDTO
+//	@Failure		500	{object}	dto.ErrorResponseDTO
+//	@Router			/users/{id}/reviews [get]
+func (h *ReviewHandler) GetUserReviews(c *gin.Context) {
+	actor, ok := reviewActor(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(utils.ErrInvalidID)
+		return
+	}
+
+	reviews, err := h.Service.GetUserReviews(uint(userID), actor)
+	if err != nil {
+		c.Error(utils.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, reviews)
+}
+
+// GetReview retrieves a single review
+//
+//	@Summary		Get a review
+//	@Description	Retrieves a review by its ID. Unpublished reviews are only visible to their author and moderators.
+//	@Tags			reviews
+//	@Security		Bearer
+//	@Produce		json
+//	@Param			id	path		int	true	"Review ID"
+//	@Success		200	{object}	dto.ReviewResponseDTO
+//	@Failure		400	{object}	dto.ErrorResponseDTO
+//	@Failure		401	{object}	dto.ErrorResponseDTO
+//	@Failure		404	{object}	dto.ErrorResponseDTO
+//	@Failure		500	{object}	dto.ErrorResponseDTO
+//	@Router			/reviews/{id} [get]
+func (h *ReviewHandler) GetReview(c *gin.Context) {
+	actor, ok := reviewActor(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
+	reviewID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(utils.ErrInvalidID)
+		return
+	}
+
+	review, err := h.Service.GetReview(uint(reviewID), actor)
+	if err != nil {
+		if err == utils.ErrNotFound {
+			c.Error(err)
+		} else {
+			c.Error(utils.ErrInternal)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
 // CreateReview creates a new review for a book
 //
 //	@Summary		Create a new review
-//	@Description	Creates a new review for a specific book
+//	@Description	Creates a new review for a specific book. Each user can review a book once; a second attempt answers 409 with a link to the existing review. Content filters may reject the review, mask blocked words and links or hold it for moderation. With pre-moderation the review is published after a moderator approves it.
 //	@Tags			reviews
 //	@Accept			json
 //	@Produce		json
+//	@Security		Bearer
 //	@Param			id		path		int							true	"Book ID"
 //	@Param			review	body		dto.CreateReviewRequestDTO	true	"Review Data"
 //	@Success		201		{object}	dto.ReviewResponseDTO
 //	@Failure		400		{object}	dto.ErrorResponseDTO
+//	@Failure		401		{object}	dto.ErrorResponseDTO
+//	@Failure		409		{object}	dto.ReviewConflictResponseDTO
+//	@Failure		422		{object}	dto.ErrorResponseDTO	"Rejected by a content filter"
+//	@Failure		429		{object}	map[string]interface{}	"Posting too many reviews"
 //	@Failure		500		{object}	dto.ErrorResponseDTO
+//	@Header			409		{string}	Location	"URL of the existing review"
+//	@Header			429		{integer}	Retry-After	"Seconds to wait before trying again"
 //	@Router			/books/{id}/reviews [post]
 func (h *ReviewHandler) CreateReview(c *gin.Context) {
+	claims, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
 	bookID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.Error(utils.ErrInvalidID)
@@ -73,8 +231,23 @@ func (h *ReviewHandler) CreateReview(c *gin.Context) {
 		return
 	}
 
-	createdReview, err := h.Service.CreateReview(uint(bookID), reviewDTO)
+	createdReview, err := h.Service.CreateReview(uint(bookID), claims.ID, reviewDTO)
 	if err != nil {
+		var exists *utils.ReviewExistsError
+		if errors.As(err, &exists) {
+			link := fmt.Sprintf("/api/v1/reviews/%d", exists.ReviewID)
+			c.Header("Location", link)
+			c.JSON(http.StatusConflict, dto.ReviewConflictResponseDTO{
+				Message:  err.Error(),
+				ReviewID: exists.ReviewID,
+				Link:     link,
+				MineLink: fmt.Sprintf("/api/v1/books/%d/reviews/mine", bookID),
+			})
+			return
+		}
+		if respondFilterRejection(c, err) {
+			return
+		}
 		c.Error(utils.ErrInternal)
 		return
 	}
@@ -82,21 +255,85 @@ func (h *ReviewHandler) CreateReview(c *gin.Context) {
 	c.JSON(http.StatusCreated, createdReview)
 }
 
+// SaveMyReview creates or replaces the current user's review of a book
+//
+//	@Summary		Create or replace my review
+//	@Description	Creates the authenticated user's review of a book, or replaces it if the user has already reviewed the book
+//	@Tags			reviews
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			id		path		int							true	"Book ID"
+//	@Param			review	body		dto.CreateReviewRequestDTO	true	"Review Data"
+//	@Success		200		{object}	dto.ReviewResponseDTO		"Review replaced"
+//	@Success		201		{object}	dto.ReviewResponseDTO		"Review created"
+//	@Failure		400		{object}	dto.ErrorResponseDTO
+//	@Failure		401		{object}	dto.ErrorResponseDTO
+//	@Failure		422		{object}	dto.ErrorResponseDTO	"Rejected by a content filter"
+//	@Failure		429		{object}	map[string]interface{}	"Posting too many reviews"
+//	@Failure		500		{object}	dto.ErrorResponseDTO
+//	@Header			429		{integer}	Retry-After	"Seconds to wait before trying again"
+//	@Router			/books/{id}/reviews/mine [put]
+func (h *ReviewHandler) SaveMyReview(c *gin.Context) {
+	claims, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
+	bookID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(utils.ErrInvalidID)
+		return
+	}
+
+	var reviewDTO dto.CreateReviewRequestDTO
+	if err := c.ShouldBindJSON(&reviewDTO); err != nil {
+		c.Error(utils.ErrBadRequest)
+		return
+	}
+
+	review, created, err := h.Service.SaveUserReview(uint(bookID), claims.ID, reviewDTO)
+	if err != nil {
+		if respondFilterRejection(c, err) {
+			return
+		}
+		c.Error(utils.ErrInternal)
+		return
+	}
+
+	if created {
+		c.JSON(http.StatusCreated, review)
+		return
+	}
+	c.JSON(http.StatusOK, review)
+}
+
 // UpdateReview updates an existing review
 //
 //	@Summary		Update a review
-//	@Description	Updates an existing review by its ID
+//	@Description	Updates an existing review by its ID. Users can update their own reviews, moderators any review. Edits pass through the content filters; moderators skip only the duplicate and rate checks. With pre-moderation, an edited review is queued for approval again.
 //	@Tags			reviews
 //	@Accept			json
 //	@Produce		json
+//	@Security		Bearer
 //	@Param			id		path		int							true	"Review ID"
 //	@Param			review	body		dto.CreateReviewRequestDTO	true	"Updated Review Data"
 //	@Success		200		{object}	dto.ReviewResponseDTO
 //	@Failure		400		{object}	dto.ErrorResponseDTO
+//	@Failure		401		{object}	dto.ErrorResponseDTO
+//	@Failure		403		{object}	dto.ErrorResponseDTO
 //	@Failure		404		{object}	dto.ErrorResponseDTO
+//	@Failure		422		{object}	dto.ErrorResponseDTO	"Rejected by a content filter"
 //	@Failure		500		{object}	dto.ErrorResponseDTO
 //	@Router			/reviews/{id} [put]
 func (h *ReviewHandler) UpdateReview(c *gin.Context) {
+	actor, ok := reviewActor(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
 	reviewID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.Error(utils.ErrInvalidID)
@@ -109,9 +346,16 @@ func (h *ReviewHandler) UpdateReview(c *gin.Context) {
 		return
 	}
 
-	updatedReview, err := h.Service.UpdateReview(uint(reviewID), reviewDTO)
+	updatedReview, err := h.Service.UpdateReview(uint(reviewID), actor, reviewDTO)
 	if err != nil {
-		c.Error(utils.ErrInternal)
+		if respondFilterRejection(c, err) {
+			return
+		}
+		if err == utils.ErrNotFound || err == utils.ErrForbidden {
+			c.Error(err)
+		} else {
+			c.Error(utils.ErrInternal)
+		}
 		return
 	}
 
@@ -121,15 +365,24 @@ func (h *ReviewHandler) UpdateReview(c *gin.Context) {
 // DeleteReview deletes a review
 //
 //	@Summary		Delete a review
-//	@Description	Deletes a review by its ID
+//	@Description	Deletes a review by its ID. Users can delete their own reviews, moderators any review.
 //	@Tags			reviews
+//	@Security		Bearer
 //	@Param			id	path	int	true	"Review ID"
 //	@Success		204
 //	@Failure		400	{object}	dto.ErrorResponseDTO
+//	@Failure		401	{object}	dto.ErrorResponseDTO
+//	@Failure		403	{object}	dto.ErrorResponseDTO
 //	@Failure		404	{object}	dto.ErrorResponseDTO
 //	@Failure		500	{object}	dto.ErrorResponseDTO
 //	@Router			/reviews/{id} [delete]
 func (h *ReviewHandler) DeleteReview(c *gin.Context) {
+	actor, ok := reviewActor(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
 	// Parametreden reviewID'yi alıyoruz
 	reviewID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -139,13 +392,18 @@ func (h *ReviewHandler) DeleteReview(c *gin.Context) {
 	}
 
 	// Yorumun var olup olmadığını kontrol ediyoruz
-	err = h.Service.DeleteReview(uint(reviewID)) // Yorum silme işlemi burada yapılacak
+	err = h.Service.DeleteReview(uint(reviewID), actor) // Yorum silme işlemi burada yapılacak
 	if err != nil {
 		// Eğer yorum bulunamadıysa, uygun hata mesajı döndür
 		if err == utils.ErrNotFound {
 			c.JSON(http.StatusNotFound, dto.ErrorResponseDTO{Message: err.Error()})
 			return
 		}
+		// Başkasının yorumunu yalnızca moderatörler silebilir
+		if err == utils.ErrForbidden {
+			c.JSON(http.StatusForbidden, dto.ErrorResponseDTO{Message: err.Error()})
+			return
+		}
 		// Silme işlemi sırasında herhangi bir hata oluşursa
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponseDTO{Message: utils.ErrInternal.Error()})
 		return
@@ -154,3 +412,230 @@ func (h *ReviewHandler) DeleteReview(c *gin.Context) {
 	// Silme işlemi başarılıysa, 204 No Content döndür
 	c.JSON(http.StatusNoContent, nil)
 }
+
+// VoteReview records whether the current user found a review helpful
+//
+//	@Summary		Vote on a review
+//	@Description	Marks a published review as helpful or unhelpful. Each user has one vote per review; voting again changes it. Authors cannot vote on their own reviews.
+//	@Tags			reviews
+//	@Security		Bearer
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int							true	"Review ID"
+//	@Param			vote	body		dto.VoteReviewRequestDTO	true	"Vote"
+//	@Success		200		{object}	dto.ReviewResponseDTO
+//	@Failure		400		{object}	dto.ErrorResponseDTO
+//	@Failure		401		{object}	dto.ErrorResponseDTO
+//	@Failure		403		{object}	dto.ErrorResponseDTO
+//	@Failure		404		{object}	dto.ErrorResponseDTO
+//	@Failure		500		{object}	dto.ErrorResponseDTO
+//	@Router			/reviews/{id}/vote [put]
+func (h *ReviewHandler) VoteReview(c *gin.Context) {
+	claims, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
+	reviewID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(utils.ErrInvalidID)
+		return
+	}
+
+	var req dto.VoteReviewRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(utils.ErrBadRequest)
+		return
+	}
+
+	review, err := h.Service.VoteReview(uint(reviewID), claims.ID, *req.Helpful)
+	if err != nil {
+		switch err {
+		case utils.ErrNotFound:
+			c.Error(err)
+		case utils.ErrOwnReviewVote:
+			c.JSON(http.StatusForbidden, dto.ErrorResponseDTO{Message: err.Error()})
+		default:
+			c.Error(utils.ErrInternal)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, review)
+}
+
+// RemoveVote withdraws the current user's vote on a review
+//
+//	@Summary		Remove my vote
+//	@Description	Withdraws the authenticated user's helpful or unhelpful vote on a review
+//	@Tags			reviews
+//	@Security		Bearer
+//	@Param			id	path	int	true	"Review ID"
+//	@Success		204
+//	@Failure		400	{object}	dto.ErrorResponseDTO
+//	@Failure		401	{object}	dto.ErrorResponseDTO
+//	@Failure		404	{object}	dto.ErrorResponseDTO
+//	@Failure		500	{object}	dto.ErrorResponseDTO
+//	@Router			/reviews/{id}/vote [delete]
+func (h *ReviewHandler) RemoveVote(c *gin.Context) {
+	claims, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
+	reviewID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(utils.ErrInvalidID)
+		return
+	}
+
+	if err := h.Service.RemoveVote(uint(reviewID), claims.ID); err != nil {
+		if err == utils.ErrNotFound {
+			c.Error(err)
+		} else {
+			c.Error(utils.ErrInternal)
+		}
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetModerationQueue lists reviews awaiting moderation
+//
+//	@Summary		Review moderation queue
+//	@Description	Retrieves a paginated list of pending reviews, oldest first
+//	@Tags			admin
+//	@Security		Bearer
+//	@Produce		json
+//	@Param			page		query		int	false	"Page number (default 1, max 500)"
+//	@Param			page_size	query		int	false	"Page size (default 20, max 100)"
+//	@Success		200			{object}	dto.ReviewListResponseDTO
+//	@Failure		500			{object}	dto.ErrorResponseDTO
+//	@Router			/admin/reviews/queue [get]
+func (h *ReviewHandler) GetModerationQueue(c *gin.Context) {
+	page, pageSize := parsePagination(c)
+
+	queue, err := h.Service.GetModerationQueue(page, pageSize)
+	if err != nil {
+		c.Error(utils.ErrInternal)
+		return
+	}
+	c.JSON(http.StatusOK, queue)
+}
+
+// ApproveReview publishes a review
+//
+//	@Summary		Approve a review
+//	@Description	Publishes a pending review, or restores a rejected or hidden one
+//	@Tags			admin
+//	@Security		Bearer
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int							true	"Review ID"
+//	@Param			body	body		dto.ModerateReviewRequestDTO	false	"Optional note"
+//	@Success		200		{object}	dto.ReviewResponseDTO
+//	@Failure		400		{object}	dto.ErrorResponseDTO
+//	@Failure		404		{object}	dto.ErrorResponseDTO
+//	@Failure		500		{object}	dto.ErrorResponseDTO
+//	@Router			/admin/reviews/{id}/approve [post]
+func (h *ReviewHandler) ApproveReview(c *gin.Context) {
+	h.moderate(c, models.ReviewApproved)
+}
+
+// RejectReview turns down a review
+//
+//	@Summary		Reject a review
+//	@Description	Rejects a review with a reason that is shown to its author. The author can edit the review to submit it again.
+//	@Tags			admin
+//	@Security		Bearer
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int							true	"Review ID"
+//	@Param			body	body		dto.ModerateReviewRequestDTO	true	"Reason"
+//	@Success		200		{object}	dto.ReviewResponseDTO
+//	@Failure		400		{object}	dto.ErrorResponseDTO
+//	@Failure		404		{object}	dto.ErrorResponseDTO
+//	@Failure		500		{object}	dto.ErrorResponseDTO
+//	@Router			/admin/reviews/{id}/reject [post]
+func (h *ReviewHandler) RejectReview(c *gin.Context) {
+	h.moderate(c, models.ReviewRejected)
+}
+
+// HideReview takes down a published review
+//
+//	@Summary		Hide a review
+//	@Description	Removes a published review from public listings with a reason that is shown to its author
+//	@Tags			admin
+//	@Security		Bearer
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int							true	"Review ID"
+//	@Param			body	body		dto.ModerateReviewRequestDTO	true	"Reason"
+//	@Success		200		{object}	dto.ReviewResponseDTO
+//	@Failure		400		{object}	dto.ErrorResponseDTO
+//	@Failure		404		{object}	dto.ErrorResponseDTO
+//	@Failure		500		{object}	dto.ErrorResponseDTO
+//	@Router			/admin/reviews/{id}/hide [post]
+func (h *ReviewHandler) HideReview(c *gin.Context) {
+	h.moderate(c, models.ReviewHidden)
+}
+
+// moderate applies a moderation decision to the review in the path
+func (h *ReviewHandler) moderate(c *gin.Context, status string) {
+	claims, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
+	reviewID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(utils.ErrInvalidID)
+		return
+	}
+
+	// The body is optional when approving
+	var req dto.ModerateReviewRequestDTO
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(utils.ErrBadRequest)
+			return
+		}
+	}
+
+	review, err := h.Service.ModerateReview(uint(reviewID), claims.ID, status, strings.TrimSpace(req.Reason))
+	if err != nil {
+		if err == utils.ErrNotFound || err == utils.ErrBadRequest {
+			c.Error(err)
+		} else {
+			c.Error(utils.ErrInternal)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, review)
+}
+
+// respondFilterRejection answers with 422, or 429 with a Retry-After header, when a content filter refused the review or reply
+func respondFilterRejection(c *gin.Context, err error) bool {
+	var retryErr *utils.RetryAfterError
+	if errors.As(err, &retryErr) {
+		abortWithRetryAfter(c, retryErr)
+		return true
+	}
+	var rejected *utils.ContentRejectedError
+	if errors.As(err, &rejected) {
+		c.JSON(http.StatusUnprocessableEntity, dto.ErrorResponseDTO{Message: err.Error()})
+		return true
+	}
+	return false
+}
+
+// reviewActor describes the current user to the review service
+func reviewActor(c *gin.Context) (services.ReviewActor, bool) {
+	claims, ok := currentUser(c)
+	if !ok {
+		return services.ReviewActor{}, false
+	}
+	return services.ReviewActor{UserID: claims.ID, Moderator: claims.HasPermission(utils.PermReviewsModerate)}, true
+}
@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"mentalartsapi/internal/dto"
+	"mentalartsapi/internal/models"
+	"mentalartsapi/internal/services"
+	"mentalartsapi/internal/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReviewReplyHandler manages threaded replies on reviews
+type ReviewReplyHandler struct {
+	Service *services.ReviewReplyService
+}
+
+// NewReviewReplyHandler creates a new ReviewReplyHandler instance
+func NewReviewReplyHandler(service *services.ReviewReplyService) *ReviewReplyHandler {
+	return &ReviewReplyHandler{Service: service}
+}
+
+// GetReplies retrieves the reply thread of a review
+//
+//	@Summary		Get replies to a review
+//	@Description	Retrieves the published replies of a review as a tree, oldest first at every level, plus the caller's own replies while they await moderation
+//	@Tags			replies
+//	@Security		Bearer
+//	@Produce		json
+//	@Param			id	path		int	true	"Review ID"
+//	@Success		200	{array}		dto.ReplyResponseDTO
+//	@Failure		400	{object}	dto.ErrorResponseDTO
+//	@Failure		401	{object}	dto.ErrorResponseDTO
+//	@Failure		404	{object}	dto.ErrorResponseDTO
+//	@Failure		500	{object}	dto.ErrorResponseDTO
+//	@Router			/reviews/{id}/replies [get]
+func (h *ReviewReplyHandler) GetReplies(c *gin.Context) {
+	actor, ok := reviewActor(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
+	reviewID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(utils.ErrInvalidID)
+		return
+	}
+
+	replies, err := h.Service.GetThread(uint(reviewID), actor)
+	if err != nil {
+		if err == utils.ErrNotFound {
+			c.Error(err)
+		} else {
+			c.Error(utils.ErrInternal)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, replies)
+}
+
+// CreateReply replies to a review or to another reply
+//
+//	@Summary		Reply to a review
+//	@Description	Adds a reply to a published review, or to one of its published replies when parent_id is set. Replies can only be nested up to a configured depth. The text passes the same content filters as reviews. With pre-moderation, or when a filter flags it, the reply is published once a moderator approves it.
+//	@Tags			replies
+//	@Security		Bearer
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int							true	"Review ID"
+//	@Param			reply	body		dto.CreateReplyRequestDTO	true	"Reply"
+//	@Success		201		{object}	dto.ReplyResponseDTO
+//	@Failure		400		{object}	dto.ErrorResponseDTO
+//	@Failure		401		{object}	dto.ErrorResponseDTO
+//	@Failure		404		{object}	dto.ErrorResponseDTO	"Review or parent reply not found"
+//	@Failure		422		{object}	dto.ErrorResponseDTO	"Rejected by a content filter"
+//	@Failure		429		{object}	map[string]interface{}	"Posting too many replies"
+//	@Failure		500		{object}	dto.ErrorResponseDTO
+//	@Header			429		{integer}	Retry-After	"Seconds to wait before trying again"
+//	@Router			/reviews/{id}/replies [post]
+func (h *ReviewReplyHandler) CreateReply(c *gin.Context) {
+	claims, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
+	reviewID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(utils.ErrInvalidID)
+		return
+	}
+
+	var req dto.CreateReplyRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(utils.ErrBadRequest)
+		return
+	}
+
+	reply, err := h.Service.CreateReply(uint(reviewID), claims.ID, req)
+	if err != nil {
+		switch err {
+		case utils.ErrNotFound:
+			c.Error(err)
+		case utils.ErrReplyTooDeep:
+			c.JSON(http.StatusBadRequest, dto.ErrorResponseDTO{Message: err.Error()})
+		default:
+			if !respondFilterRejection(c, err) {
+				c.Error(utils.ErrInternal)
+			}
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, reply)
+}
+
+// UpdateReply changes the text of a reply
+//
+//	@Summary		Update a reply
+//	@Description	Changes the text of a reply. Users can edit their own replies, moderators any reply. Edits pass the content filters and go back to the moderation queue like review edits.
+//	@Tags			replies
+//	@Security		Bearer
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int							true	"Reply ID"
+//	@Param			reply	body		dto.UpdateReplyRequestDTO	true	"Reply"
+//	@Success		200		{object}	dto.ReplyResponseDTO
+//	@Failure		400		{object}	dto.ErrorResponseDTO
+//	@Failure		401		{object}	dto.ErrorResponseDTO
+//	@Failure		403		{object}	dto.ErrorResponseDTO
+//	@Failure		404		{object}	dto.ErrorResponseDTO
+//	@Failure		422		{object}	dto.ErrorResponseDTO	"Rejected by a content filter"
+//	@Failure		500		{object}	dto.ErrorResponseDTO
+//	@Router			/replies/{id} [put]
+func (h *ReviewReplyHandler) UpdateReply(c *gin.Context) {
+	actor, ok := reviewActor(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
+	replyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(utils.ErrInvalidID)
+		return
+	}
+
+	var req dto.UpdateReplyRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(utils.ErrBadRequest)
+		return
+	}
+
+	reply, err := h.Service.UpdateReply(uint(replyID), actor, req)
+	if err != nil {
+		if err == utils.ErrNotFound || err == utils.ErrForbidden {
+			c.Error(err)
+		} else if !respondFilterRejection(c, err) {
+			c.Error(utils.ErrInternal)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, reply)
+}
+
+// DeleteReply deletes a reply and the replies below it
+//
+//	@Summary		Delete a reply
+//	@Description	Deletes a reply together with all replies nested below it. Users can delete their own replies, moderators any reply.
+//	@Tags			replies
+//	@Security		Bearer
+//	@Param			id	path	int	true	"Reply ID"
+//	@Success		204
+//	@Failure		400	{object}	dto.ErrorResponseDTO
+//	@Failure		401	{object}	dto.ErrorResponseDTO
+//	@Failure		403	{object}	dto.ErrorResponseDTO
+//	@Failure		404	{object}	dto.ErrorResponseDTO
+//	@Failure		500	{object}	dto.ErrorResponseDTO
+//	@Router			/replies/{id} [delete]
+func (h *ReviewReplyHandler) DeleteReply(c *gin.Context) {
+	actor, ok := reviewActor(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
+	replyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(utils.ErrInvalidID)
+		return
+	}
+
+	if err := h.Service.DeleteReply(uint(replyID), actor); err != nil {
+		if err == utils.ErrNotFound || err == utils.ErrForbidden {
+			c.Error(err)
+		} else {
+			c.Error(utils.ErrInternal)
+		}
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetModerationQueue lists replies awaiting moderation
+//
+//	@Summary		Reply moderation queue
+//	@Description	Retrieves a paginated list of pending replies, oldest first
+//	@Tags			admin
+//	@Security		Bearer
+//	@Produce		json
+//	@Param			page		query		int	false	"Page number (default 1, max 500)"
+//	@Param			page_size	query		int	false	"Page size (default 20, max 100)"
+//	@Success		200			{object}	dto.ReplyListResponseDTO
+//	@Failure		500			{object}	dto.ErrorResponseDTO
+//	@Router			/admin/replies/queue [get]
+func (h *ReviewReplyHandler) GetModerationQueue(c *gin.Context) {
+	page, pageSize := parsePagination(c)
+
+	queue, err := h.Service.GetModerationQueue(page, pageSize)
+	if err != nil {
+		c.Error(utils.ErrInternal)
+		return
+	}
+	c.JSON(http.StatusOK, queue)
+}
+
+// ApproveReply publishes a reply
+//
+//	@Summary		Approve a reply
+//	@Description	Publishes a pending reply, or restores a rejected or hidden one
+//	@Tags			admin
+//	@Security		Bearer
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int								true	"Reply ID"
+//	@Param			body	body		dto.ModerateReviewRequestDTO	false	"Optional note"
+//	@Success		200		{object}	dto.ReplyResponseDTO
+//	@Failure		400		{object}	dto.ErrorResponseDTO
+//	@Failure		404		{object}	dto.ErrorResponseDTO
+//	@Failure		500		{object}	dto.ErrorResponseDTO
+//	@Router			/admin/replies/{id}/approve [post]
+func (h *ReviewReplyHandler) ApproveReply(c *gin.Context) {
+	h.moderate(c, models.ReviewApproved)
+}
+
+// RejectReply turns down a reply
+//
+//	@Summary		Reject a reply
+//	@Description	Rejects a reply with a reason that is shown to its author. The author can edit the reply to submit it again.
+//	@Tags			admin
+//	@Security		Bearer
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int								true	"Reply ID"
+//	@Param			body	body		dto.ModerateReviewRequestDTO	true	"Reason"
+//	@Success		200		{object}	dto.ReplyResponseDTO
+//	@Failure		400		{object}	dto.ErrorResponseDTO
+//	@Failure		404		{object}	dto.ErrorResponseDTO
+//	@Failure		500		{object}	dto.ErrorResponseDTO
+//	@Router			/admin/replies/{id}/reject [post]
+func (h *ReviewReplyHandler) RejectReply(c *gin.Context) {
+	h.moderate(c, models.ReviewRejected)
+}
+
+// HideReply takes down a published reply
+//
+//	@Summary		Hide a reply
+//	@Description	Removes a published reply, and the replies below it, from the thread with a reason that is shown to its author
+//	@Tags			admin
+//	@Security		Bearer
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int								true	"Reply ID"
+//	@Param			body	body		dto.ModerateReviewRequestDTO	true	"Reason"
+//	@Success		200		{object}	dto.ReplyResponseDTO
+//	@Failure		400		{object}	dto.ErrorResponseDTO
+//	@Failure		404		{object}	dto.ErrorResponseDTO
+//	@Failure		500		{object}	dto.ErrorResponseDTO
+//	@Router			/admin/replies/{id}/hide [post]
+func (h *ReviewReplyHandler) HideReply(c *gin.Context) {
+	h.moderate(c, models.ReviewHidden)
+}
+
+// moderate applies a moderation decision to the reply in the path
+func (h *ReviewReplyHandler) moderate(c *gin.Context, status string) {
+	claims, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
+	replyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(utils.ErrInvalidID)
+		return
+	}
+
+	// The body is optional when approving
+	var req dto.ModerateReviewRequestDTO
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(utils.ErrBadRequest)
+			return
+		}
+	}
+
+	reply, err := h.Service.ModerateReply(uint(replyID), claims.ID, status, strings.TrimSpace(req.Reason))
+	if err != nil {
+		if err == utils.ErrNotFound || err == utils.ErrBadRequest {
+			c.Error(err)
+		} else {
+			c.Error(utils.ErrInternal)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, reply)
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"mentalartsapi/internal/dto"
+	"mentalartsapi/internal/services"
+	"mentalartsapi/internal/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReviewReportHandler manages reports about abusive reviews
+type ReviewReportHandler struct {
+	Service *services.ReviewReportService
+}
+
+// NewReviewReportHandler creates a new ReviewReportHandler instance
+func NewReviewReportHandler(service *services.ReviewReportService) *ReviewReportHandler {
+	return &ReviewReportHandler{Service: service}
+}
+
+// ReportReview flags a review for the moderators
+//
+//	@Summary		Report a review
+//	@Description	Reports a published review as spam, abuse, offensive, a spoiler, off topic or other. Each user can report a review once. Reviews with enough open reports are hidden until a moderator looks at them.
+//	@Tags			reviews
+//	@Security		Bearer
+//	@Accept			json
+//	@Param			id		path	int							true	"Review ID"
+//	@Param			report	body	dto.ReportReviewRequestDTO	true	"Report"
+//	@Success		204
+//	@Failure		400	{object}	dto.ErrorResponseDTO
+//	@Failure		401	{object}	dto.ErrorResponseDTO
+//	@Failure		403	{object}	dto.ErrorResponseDTO
+//	@Failure		404	{object}	dto.ErrorResponseDTO
+//	@Failure		409	{object}	dto.ErrorResponseDTO
+//	@Failure		500	{object}	dto.ErrorResponseDTO
+//	@Router			/reviews/{id}/reports [post]
+func (h *ReviewReportHandler) ReportReview(c *gin.Context) {
+	claims, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
+	reviewID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(utils.ErrInvalidID)
+		return
+	}
+
+	var req dto.ReportReviewRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(utils.ErrBadRequest)
+		return
+	}
+	req.Details = strings.TrimSpace(req.Details)
+
+	if err := h.Service.ReportReview(uint(reviewID), claims.ID, req); err != nil {
+		switch err {
+		case utils.ErrNotFound:
+			c.Error(err)
+		case utils.ErrOwnReviewReport:
+			c.JSON(http.StatusForbidden, dto.ErrorResponseDTO{Message: err.Error()})
+		case utils.ErrAlreadyReported:
+			c.JSON(http.StatusConflict, dto.ErrorResponseDTO{Message: err.Error()})
+		default:
+			c.Error(utils.ErrInternal)
+		}
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetReportedReviews lists reviews with open reports
+//
+//	@Summary		List reported reviews
+//	@Description	Retrieves a paginated list of reviews with open reports, grouped by review and ordered by the number of reports
+//	@Tags			admin
+//	@Security		Bearer
+//	@Produce		json
+//	@Param			page		query		int	false	"Page number (default 1, max 500)"
+//	@Param			page_size	query		int	false	"Page size (default 20, max 100)"
+//	@Success		200			{object}	dto.ReportedReviewListResponseDTO
+//	@Failure		500			{object}	dto.ErrorResponseDTO
+//	@Router			/admin/reviews/reports [get]
+func (h *ReviewReportHandler) GetReportedReviews(c *gin.Context) {
+	page, pageSize := parsePagination(c)
+
+	reports, err := h.Service.GetReportedReviews(page, pageSize)
+	if err != nil {
+		c.Error(utils.ErrInternal)
+		return
+	}
+	c.JSON(http.StatusOK, reports)
+}
+
+// ResolveReports closes the open reports of a review
+//
+//	@Summary		Resolve reports
+//	@Description	Closes all open reports of a review. "dismiss" keeps the review and restores it if it was hidden automatically; "hide" takes it down with a reason shown to its author.
+//	@Tags			admin
+//	@Security		Bearer
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int								true	"Review ID"
+//	@Param			body	body		dto.ResolveReportsRequestDTO	true	"Decision"
+//	@Success		200		{object}	dto.ReviewResponseDTO
+//	@Failure		400		{object}	dto.ErrorResponseDTO
+//	@Failure		401		{object}	dto.ErrorResponseDTO
+//	@Failure		404		{object}	dto.ErrorResponseDTO	"Review not found or no open reports"
+//	@Failure		500		{object}	dto.ErrorResponseDTO
+//	@Router			/admin/reviews/{id}/reports/resolve [post]
+func (h *ReviewReportHandler) ResolveReports(c *gin.Context) {
+	claims, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
+	reviewID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(utils.ErrInvalidID)
+		return
+	}
+
+	var req dto.ResolveReportsRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(utils.ErrBadRequest)
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+
+	review, err := h.Service.ResolveReports(uint(reviewID), claims.ID, req)
+	if err != nil {
+		if err == utils.ErrNotFound || err == utils.ErrBadRequest {
+			c.Error(err)
+		} else {
+			c.Error(utils.ErrInternal)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, review)
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"mentalartsapi/internal/dto"
+	"mentalartsapi/internal/services"
+	"mentalartsapi/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionHandler lists and revokes login sessions, for the current user and for admins
+type SessionHandler struct {
+	AuthService *services.AuthService
+}
+
+// NewSessionHandler creates a new SessionHandler instance
+func NewSessionHandler(authService *services.AuthService) *SessionHandler {
+	return &SessionHandler{AuthService: authService}
+}
+
+// GetMySessions lists the devices the current user is signed in on
+//
+//	@Summary		List my sessions
+//	@Description	Lists the active sessions of the authenticated user with device, IP address and last use. The session of the current access token is marked as current.
+//	@Tags			sessions
+//	@Security		Bearer
+//	@Produce		json
+//	@Success		200	{array}		dto.SessionResponseDTO
+//	@Failure		401	{object}	dto.ErrorResponseDTO
+//	@Failure		500	{object}	dto.ErrorResponseDTO
+//	@Router			/me/sessions [get]
+func (h *SessionHandler) GetMySessions(c *gin.Context) {
+	claims, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
+	sessions, err := h.AuthService.ListSessions(claims.ID, claims.SessionID)
+	if err != nil {
+		c.Error(utils.ErrInternal)
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeMySession signs the current user out of one device
+//
+//	@Summary		Revoke one of my sessions
+//	@Description	Signs the authenticated user out of a session. Its refresh token and access tokens stop working immediately.
+//	@Tags			sessions
+//	@Security		Bearer
+//	@Param			id	path	int	true	"Session ID"
+//	@Success		204
+//	@Failure		400	{object}	dto.ErrorResponseDTO
+//	@Failure		401	{object}	dto.ErrorResponseDTO
+//	@Failure		404	{object}	dto.ErrorResponseDTO
+//	@Failure		500	{object}	dto.ErrorResponseDTO
+//	@Router			/me/sessions/{id} [delete]
+func (h *SessionHandler) RevokeMySession(c *gin.Context) {
+	claims, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
+	sessionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(utils.ErrInvalidID)
+		return
+	}
+
+	h.revoke(c, claims.ID, uint(sessionID))
+}
+
+// GetUserSessions lists the sessions of any user
+//
+//	@Summary		List a user's sessions
+//	@Description	Lists the active sessions of a user
+//	@Tags			admin
+//	@Security		Bearer
+//	@Produce		json
+//	@Param			id	path		int	true	"User ID"
+//	@Success		200	{array}		dto.SessionResponseDTO
+//	@Failure		400	{object}	dto.ErrorResponseDTO
+//	@Failure		404	{object}	dto.ErrorResponseDTO
+//	@Failure		500	{object}	dto.ErrorResponseDTO
+//	@Router			/admin/users/{id}/sessions [get]
+func (h *SessionHandler) GetUserSessions(c *gin.Context) {
+	userID, ok := h.existingUserID(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.AuthService.ListSessions(userID, 0)
+	if err != nil {
+		c.Error(utils.ErrInternal)
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeUserSession signs any user out of one session
+//
+//	@Summary		Revoke a user's session
+//	@Description	Signs a user out of one session. Its refresh token and access tokens stop working immediately.
+//	@Tags			admin
+//	@Security		Bearer
+//	@Param			id			path	int	true	"User ID"
+//	@Param			session_id	path	int	true	"Session ID"
+//	@Success		204
+//	@Failure		400	{object}	dto.ErrorResponseDTO
+//	@Failure		404	{object}	dto.ErrorResponseDTO
+//	@Failure		500	{object}	dto.ErrorResponseDTO
+//	@Router			/admin/users/{id}/sessions/{session_id} [delete]
+func (h *SessionHandler) RevokeUserSession(c *gin.Context) {
+	userID, ok := h.existingUserID(c)
+	if !ok {
+		return
+	}
+
+	sessionID, err := strconv.Atoi(c.Param("session_id"))
+	if err != nil {
+		c.Error(utils.ErrInvalidID)
+		return
+	}
+
+	h.revoke(c, userID, uint(sessionID))
+}
+
+// revoke ends a session of the given user and writes the response
+func (h *SessionHandler) revoke(c *gin.Context, userID, sessionID uint) {
+	if err := h.AuthService.RevokeSession(userID, sessionID); err != nil {
+		if err == utils.ErrNotFound {
+			c.Error(err)
+			return
+		}
+		c.Error(utils.ErrInternal)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// existingUserID reads the user ID path parameter and checks that the user exists
+func (h *SessionHandler) existingUserID(c *gin.Context) (uint, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(utils.ErrInvalidID)
+		return 0, false
+	}
+
+	if _, err := h.AuthService.GetUserByID(uint(id)); err != nil {
+		c.Error(utils.ErrNotFound)
+		return 0, false
+	}
+	return uint(id), true
+}
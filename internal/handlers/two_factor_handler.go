@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"mentalartsapi/internal/dto"
+	"mentalartsapi/internal/services"
+	"mentalartsapi/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TwoFactorHandler manages the current user's two-factor authentication
+type TwoFactorHandler struct {
+	Service *services.TwoFactorService
+}
+
+// NewTwoFactorHandler creates a new TwoFactorHandler instance
+func NewTwoFactorHandler(service *services.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{Service: service}
+}
+
+// GetStatus returns the two-factor status of the current user
+//
+//	@Summary		Get two-factor status
+//	@Description	Returns whether two-factor authentication is enabled or mandatory and how many recovery codes are left
+//	@Tags			two-factor
+//	@Security		Bearer
+//	@Produce		json
+//	@Success		200	{object}	dto.TwoFactorStatusDTO
+//	@Failure		401	{object}	dto.ErrorResponseDTO
+//	@Failure		500	{object}	dto.ErrorResponseDTO
+//	@Router			/me/2fa [get]
+func (h *TwoFactorHandler) GetStatus(c *gin.Context) {
+	claims, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
+	status, err := h.Service.GetStatus(claims.ID)
+	if err != nil {
+		h.handleTwoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// Setup starts TOTP enrolment
+//
+//	@Summary		Start two-factor setup
+//	@Description	Generates a new TOTP secret and the otpauth:// provisioning URI to show as a QR code. Two-factor authentication is only enabled after confirming a code at /me/2fa/enable.
+//	@Tags			two-factor
+//	@Security		Bearer
+//	@Produce		json
+//	@Success		200	{object}	dto.TwoFactorSetupResponseDTO
+//	@Failure		401	{object}	dto.ErrorResponseDTO
+//	@Failure		409	{object}	dto.ErrorResponseDTO
+//	@Failure		500	{object}	dto.ErrorResponseDTO
+//	@Router			/me/2fa/setup [post]
+func (h *TwoFactorHandler) Setup(c *gin.Context) {
+	claims, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
+	setup, err := h.Service.BeginSetup(claims.ID)
+	if err != nil {
+		h.handleTwoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, setup)
+}
+
+// Enable confirms TOTP enrolment
+//
+//	@Summary		Enable two-factor authentication
+//	@Description	Confirms the secret from /me/2fa/setup with a code from the authenticator app. Returns one-time recovery codes that are only shown once.
+//	@Tags			two-factor
+//	@Security		Bearer
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		dto.TwoFactorCodeRequestDTO	true	"TOTP Code"
+//	@Success		200		{object}	dto.RecoveryCodesResponseDTO
+//	@Failure		400		{object}	dto.ErrorResponseDTO
+//	@Failure		401		{object}	dto.ErrorResponseDTO
+//	@Failure		409		{object}	dto.ErrorResponseDTO
+//	@Failure		500		{object}	dto.ErrorResponseDTO
+//	@Router			/me/2fa/enable [post]
+func (h *TwoFactorHandler) Enable(c *gin.Context) {
+	claims, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
+	var req dto.TwoFactorCodeRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(utils.ErrBadRequest)
+		return
+	}
+
+	codes, err := h.Service.EnableTwoFactor(claims.ID, req.Code)
+	if err != nil {
+		h.handleTwoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, codes)
+}
+
+// Disable turns two-factor authentication off
+//
+//	@Summary		Disable two-factor authentication
+//	@Description	Turns two-factor authentication off after checking a TOTP or recovery code. Not allowed when it is mandatory for the user's role.
+//	@Tags			two-factor
+//	@Security		Bearer
+//	@Accept			json
+//	@Param			body	body	dto.TwoFactorCodeRequestDTO	true	"TOTP or Recovery Code"
+//	@Success		204
+//	@Failure		400	{object}	dto.ErrorResponseDTO
+//	@Failure		401	{object}	dto.ErrorResponseDTO
+//	@Failure		403	{object}	dto.ErrorResponseDTO
+//	@Failure		500	{object}	dto.ErrorResponseDTO
+//	@Router			/me/2fa/disable [post]
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	claims, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
+	var req dto.TwoFactorCodeRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(utils.ErrBadRequest)
+		return
+	}
+
+	if err := h.Service.DisableTwoFactor(claims.ID, req.Code); err != nil {
+		h.handleTwoFactorError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes
+//
+//	@Summary		Regenerate recovery codes
+//	@Description	Replaces all recovery codes after checking a TOTP code. Old codes stop working.
+//	@Tags			two-factor
+//	@Security		Bearer
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		dto.TwoFactorCodeRequestDTO	true	"TOTP Code"
+//	@Success		200		{object}	dto.RecoveryCodesResponseDTO
+//	@Failure		400		{object}	dto.ErrorResponseDTO
+//	@Failure		401		{object}	dto.ErrorResponseDTO
+//	@Failure		500		{object}	dto.ErrorResponseDTO
+//	@Router			/me/2fa/recovery-codes [post]
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	claims, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: "Unable to retrieve user claims"})
+		return
+	}
+
+	var req dto.TwoFactorCodeRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(utils.ErrBadRequest)
+		return
+	}
+
+	codes, err := h.Service.RegenerateRecoveryCodes(claims.ID, req.Code)
+	if err != nil {
+		h.handleTwoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, codes)
+}
+
+// handleTwoFactorError maps two-factor errors to responses
+func (h *TwoFactorHandler) handleTwoFactorError(c *gin.Context, err error) {
+	switch err {
+	case utils.ErrNotFound, utils.ErrBadRequest:
+		c.Error(err)
+	case utils.ErrInvalidTwoFactor:
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponseDTO{Message: err.Error()})
+	case utils.ErrTwoFactorEnabled:
+		c.JSON(http.StatusConflict, dto.ErrorResponseDTO{Message: err.Error()})
+	case utils.ErrTwoFactorNotSetUp:
+		c.JSON(http.StatusBadRequest, dto.ErrorResponseDTO{Message: err.Error()})
+	case utils.ErrTwoFactorRequired:
+		c.JSON(http.StatusForbidden, dto.ErrorResponseDTO{Message: err.Error()})
+	default:
+		c.Error(utils.ErrInternal)
+	}
+}
@@ -0,0 +1,59 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LogMailer writes emails to the application log instead of sending them.
+// Bodies carry single-use tokens, so only the recipient, subject and body size are logged;
+// use the file driver to read the emails during local development.
+type LogMailer struct {
+	From string
+}
+
+func NewLogMailer(from string) *LogMailer {
+	return &LogMailer{From: from}
+}
+
+func (m *LogMailer) Send(msg Message) error {
+	log.Printf("Mail to %s: %s (%d byte body not logged)", msg.To, msg.Subject, len(msg.Body))
+	return nil
+}
+
+// FileMailer stores every email as an .eml file in a directory.
+// It is meant for local development and manual testing.
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+func NewFileMailer(dir, from string) *FileMailer {
+	return &FileMailer{Dir: dir, From: from}
+}
+
+func (m *FileMailer) Send(msg Message) error {
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), sanitizeFileName(msg.To))
+	return os.WriteFile(filepath.Join(m.Dir, name), formatMessage(m.From, msg), 0o644)
+}
+
+// sanitizeFileName keeps only characters that are safe in file names
+func sanitizeFileName(s string) string {
+	out := make([]rune, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_', r == '@':
+			out = append(out, r)
+		default:
+			out = append(out, '_')
+		}
+	}
+	return string(out)
+}
@@ -0,0 +1,33 @@
+package mailer
+
+import (
+	"log"
+	"mentalartsapi/config"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers emails. Implementations must be safe for concurrent use.
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// NewMailer creates the mailer selected by the MAIL_DRIVER setting
+func NewMailer(cfg config.MailConfig) Mailer {
+	switch cfg.Driver {
+	case "smtp":
+		return NewSMTPMailer(cfg)
+	case "file":
+		return NewFileMailer(cfg.FileDir, cfg.From)
+	case "log":
+		return NewLogMailer(cfg.From)
+	default:
+		log.Fatalf("Unknown MAIL_DRIVER %q, expected smtp, file or log", cfg.Driver)
+		return nil
+	}
+}
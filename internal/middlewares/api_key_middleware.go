@@ -0,0 +1,64 @@
+package middlewares
+
+import (
+	"log"
+	"mentalartsapi/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyAuthenticator resolves an API key to the claims of its owner
+type APIKeyAuthenticator interface {
+	Authenticate(rawKey string) (*utils.JWTClaims, error)
+}
+
+// APIKeyAuthMiddleware authenticates requests that carry an X-API-Key header.
+// It runs before JWTAuthMiddleware; requests without the header are passed on
+// untouched so they can authenticate with a Bearer token instead. Keys are looked up
+// on every request, so a deactivated owner or a revoked key is rejected at once.
+func APIKeyAuthMiddleware(authenticator APIKeyAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rawKey := c.GetHeader("X-API-Key")
+		if rawKey == "" {
+			c.Next()
+			return
+		}
+
+		claims, err := authenticator.Authenticate(rawKey)
+		if err != nil {
+			switch err {
+			case utils.ErrInvalidAPIKey:
+				c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			case utils.ErrAccountDisabled:
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			default:
+				log.Println("Error authenticating API key:", err)
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Unable to verify API key"})
+			}
+			c.Abort()
+			return
+		}
+
+		// Attach the claims to the context for later use
+		c.Set("user", claims)
+		c.Set("auth_method", "api_key")
+
+		c.Next()
+	}
+}
+
+// RequireInteractiveLogin rejects requests authenticated with an API key.
+// Routes that change the caller's own account use it so a leaked key cannot
+// take the account over, for example by changing its email address or minting new keys.
+func RequireInteractiveLogin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("auth_method") == "api_key" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "This route cannot be used with an API key"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
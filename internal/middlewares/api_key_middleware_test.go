@@ -0,0 +1,107 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mentalartsapi/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// stubAuthenticator accepts the key "good" and fails every other key with err
+type stubAuthenticator struct {
+	err   error
+	calls int
+}
+
+func (s *stubAuthenticator) Authenticate(rawKey string) (*utils.JWTClaims, error) {
+	s.calls++
+	if rawKey == "good" {
+		return &utils.JWTClaims{ID: 42, Permissions: []string{utils.PermBooksWrite}}, nil
+	}
+	return nil, s.err
+}
+
+func serveAPIKey(key string, handlers ...gin.HandlerFunc) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.GET("/", append(handlers, func(c *gin.Context) { c.Status(http.StatusNoContent) })...)
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	if key != "" {
+		request.Header.Set("X-API-Key", key)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestAPIKeyAuthMiddlewareWithoutHeader(t *testing.T) {
+	authenticator := &stubAuthenticator{}
+	var authenticated bool
+	recorder := serveAPIKey("", APIKeyAuthMiddleware(authenticator), func(c *gin.Context) {
+		_, authenticated = c.Get("user")
+	})
+
+	if recorder.Code != http.StatusNoContent || authenticated || authenticator.calls != 0 {
+		t.Errorf("got status %d, user set %v, %d lookups; want the request passed on untouched",
+			recorder.Code, authenticated, authenticator.calls)
+	}
+}
+
+func TestAPIKeyAuthMiddlewareErrors(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want int
+	}{
+		{utils.ErrInvalidAPIKey, http.StatusUnauthorized},
+		{utils.ErrAccountDisabled, http.StatusForbidden},
+		// A database failure must not let the request through unauthenticated
+		{errors.New("connection refused"), http.StatusServiceUnavailable},
+	} {
+		var reached bool
+		recorder := serveAPIKey("bad", APIKeyAuthMiddleware(&stubAuthenticator{err: tc.err}), func(c *gin.Context) {
+			reached = true
+		})
+		if recorder.Code != tc.want || reached {
+			t.Errorf("%v: status = %d (handler reached: %v), want %d", tc.err, recorder.Code, reached, tc.want)
+		}
+	}
+}
+
+func TestAPIKeyAuthMiddlewareSetsClaims(t *testing.T) {
+	var claims *utils.JWTClaims
+	var method string
+	recorder := serveAPIKey("good",
+		APIKeyAuthMiddleware(&stubAuthenticator{}),
+		// The key stands in for the Bearer token
+		JWTAuthMiddleware(),
+		RequirePermission(utils.PermBooksWrite),
+		func(c *gin.Context) {
+			claims, _ = currentClaims(c)
+			method = c.GetString("auth_method")
+		},
+	)
+
+	if recorder.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusNoContent, recorder.Body)
+	}
+	if claims == nil || claims.ID != 42 || method != "api_key" {
+		t.Errorf("claims = %+v, auth_method = %q", claims, method)
+	}
+}
+
+func TestRequireInteractiveLogin(t *testing.T) {
+	authenticator := &stubAuthenticator{}
+
+	if code := serveAPIKey("good", APIKeyAuthMiddleware(authenticator), RequireInteractiveLogin()).Code; code != http.StatusForbidden {
+		t.Errorf("with an API key: status = %d, want %d", code, http.StatusForbidden)
+	}
+
+	loggedIn := func(c *gin.Context) { c.Set("user", &utils.JWTClaims{ID: 42}) }
+	if code := serveAPIKey("", loggedIn, RequireInteractiveLogin()).Code; code != http.StatusNoContent {
+		t.Errorf("with a token: status = %d, want %d", code, http.StatusNoContent)
+	}
+}
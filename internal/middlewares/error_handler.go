@@ -21,6 +21,8 @@ func ErrorHandlerMiddleware() gin.HandlerFunc {
 				c.JSON(http.StatusNotFound, dto.ErrorResponseDTO{Message: err.Err.Error()})
 			case utils.ErrBadRequest:
 				c.JSON(http.StatusBadRequest, dto.ErrorResponseDTO{Message: err.Err.Error()})
+			case utils.ErrForbidden:
+				c.JSON(http.StatusForbidden, dto.ErrorResponseDTO{Message: err.Err.Error()})
 			default:
 				c.JSON(http.StatusInternalServerError, dto.ErrorResponseDTO{Message: utils.ErrInternal.Error()})
 			}
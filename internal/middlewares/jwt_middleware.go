@@ -1,17 +1,24 @@
 package middlewares
 
 import (
+	"mentalartsapi/config"
+	"mentalartsapi/internal/cache"
 	"mentalartsapi/internal/utils"
 	"net/http"
 	"strings"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 )
 
 // JWTAuthMiddleware is used for user authentication
 func JWTAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// The request was already authenticated with an API key
+		if _, exists := c.Get("user"); exists {
+			c.Next()
+			return
+		}
+
 		// Get the token from the Authorization header
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -30,12 +37,34 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 
 		// Parse and validate the JWT token
 		claims := &utils.JWTClaims{}
-		_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte("your-secret-key"), nil
-		})
+		if err := config.JWT.Parse(tokenString, claims); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			c.Abort()
+			return
+		}
 
+		// Reject tokens that were revoked before they expired
+		revoked, err := isTokenRevoked(claims)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Unable to verify token"})
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			c.Abort()
+			return
+		}
+
+		// Reject users that were deactivated by an admin
+		disabled, err := cache.IsUserDisabled(claims.ID)
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Unable to verify token"})
+			c.Abort()
+			return
+		}
+		if disabled {
+			c.JSON(http.StatusForbidden, gin.H{"error": utils.ErrAccountDisabled.Error()})
 			c.Abort()
 			return
 		}
@@ -47,8 +76,33 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 	}
 }
 
-// AdminOnly middleware ensures that only admins can access certain routes
-func AdminOnly() gin.HandlerFunc {
+// isTokenRevoked checks the token against the denylist, its revoked session and the user's "tokens valid after" marker
+func isTokenRevoked(claims *utils.JWTClaims) (bool, error) {
+	if claims.Id == "" {
+		return true, nil
+	}
+
+	denied, err := cache.IsTokenDenied(claims.Id)
+	if err != nil || denied {
+		return denied, err
+	}
+
+	if claims.SessionID != 0 {
+		revoked, err := cache.IsSessionRevoked(claims.SessionID)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	validAfter, err := cache.GetTokensValidAfter(claims.ID)
+	if err != nil {
+		return false, err
+	}
+	return claims.IssuedAtMillis() < validAfter, nil
+}
+
+// RequirePermission middleware ensures that the user's token grants the given permission
+func RequirePermission(permission string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get the user claims from the context
 		userClaims, _ := c.Get("user")
@@ -59,9 +113,8 @@ func AdminOnly() gin.HandlerFunc {
 			return
 		}
 
-		// Check if the user is an admin
-		if claims.Role != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have the required permissions"})
+		if !claims.HasPermission(permission) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have the required permissions", "required_permission": permission})
 			c.Abort()
 			return
 		}
@@ -0,0 +1,235 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mentalartsapi/config"
+	"mentalartsapi/internal/cache"
+	"mentalartsapi/internal/testutil"
+	"mentalartsapi/internal/utils"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// useTestKeys signs and verifies access tokens with a throwaway HS256 key for the duration of the test
+func useTestKeys(t *testing.T) {
+	t.Helper()
+
+	key, err := utils.NewSigningKey("test", "HS256", []byte("middleware-test-secret-of-32-bytes!"))
+	if err != nil {
+		t.Fatalf("NewSigningKey: %v", err)
+	}
+	previousKeys, previousAuth := config.JWT, config.Auth
+	config.JWT = &utils.KeySet{
+		Keys:      map[string]*utils.SigningKey{key.ID: key},
+		ActiveKID: key.ID,
+		Issuer:    "mentalartsapi",
+		Audience:  "mentalartsapi",
+	}
+	config.Auth.AccessTokenTTL = 15 * time.Minute
+	t.Cleanup(func() {
+		config.JWT, config.Auth = previousKeys, previousAuth
+	})
+}
+
+// accessToken signs an access token for user 7 in session 3, issued at issuedAt
+func accessToken(t *testing.T, jti string, issuedAt time.Time) string {
+	t.Helper()
+
+	token, err := config.JWT.Sign(&utils.JWTClaims{
+		ID:          7,
+		Email:       "jane@example.com",
+		Role:        utils.RoleUser,
+		Permissions: []string{utils.PermReviewsWrite},
+		SessionID:   3,
+		IssuedAtMs:  issuedAt.UnixMilli(),
+		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			IssuedAt:  issuedAt.Unix(),
+			ExpiresAt: issuedAt.Add(config.Auth.AccessTokenTTL).Unix(),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	return token
+}
+
+// serve runs one request with the given Authorization header through the handlers
+func serve(authorization string, handlers ...gin.HandlerFunc) *httptest.ResponseRecorder {
+	router := gin.New()
+	handlers = append(handlers, func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	router.GET("/", handlers...)
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	if authorization != "" {
+		request.Header.Set("Authorization", authorization)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestJWTAuthMiddlewareAcceptsValidToken(t *testing.T) {
+	useTestKeys(t)
+	testutil.UseFakeRedis(t)
+
+	var claims *utils.JWTClaims
+	recorder := serve("Bearer "+accessToken(t, "jti-valid", time.Now()), JWTAuthMiddleware(), func(c *gin.Context) {
+		claims, _ = currentClaims(c)
+	})
+
+	if recorder.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusNoContent, recorder.Body)
+	}
+	if claims == nil || claims.ID != 7 || claims.SessionID != 3 {
+		t.Errorf("claims in the context = %+v", claims)
+	}
+}
+
+func TestJWTAuthMiddlewareRejectsMalformedRequests(t *testing.T) {
+	useTestKeys(t)
+	testutil.UseFakeRedis(t)
+
+	expired := accessToken(t, "jti-expired", time.Now().Add(-time.Hour))
+	valid := accessToken(t, "jti-tampered", time.Now())
+	tampered := valid[:len(valid)-2] + "xx"
+
+	for name, authorization := range map[string]string{
+		"no header":          "",
+		"not a bearer":       "Basic amFuZTpzZWNyZXQ=",
+		"expired token":      "Bearer " + expired,
+		"tampered token":     "Bearer " + tampered,
+		"not a token at all": "Bearer nonsense",
+	} {
+		if code := serve(authorization, JWTAuthMiddleware()).Code; code != http.StatusUnauthorized {
+			t.Errorf("%s: status = %d, want %d", name, code, http.StatusUnauthorized)
+		}
+	}
+}
+
+func TestJWTAuthMiddlewareRevocation(t *testing.T) {
+	useTestKeys(t)
+	testutil.UseFakeRedis(t)
+	now := time.Now()
+
+	t.Run("denied token", func(t *testing.T) {
+		token := accessToken(t, "jti-denied", now)
+		if err := cache.DenyToken("jti-denied", now.Add(time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+		assertRevoked(t, serve("Bearer "+token, JWTAuthMiddleware()))
+
+		// Other tokens of the user are unaffected
+		if code := serve("Bearer "+accessToken(t, "jti-other", now), JWTAuthMiddleware()).Code; code != http.StatusNoContent {
+			t.Errorf("another token: status = %d, want %d", code, http.StatusNoContent)
+		}
+	})
+
+	t.Run("revoked session", func(t *testing.T) {
+		token := accessToken(t, "jti-session", now)
+		if err := cache.RevokeSessionTokens(3); err != nil {
+			t.Fatal(err)
+		}
+		defer config.Redis.Del(config.Redis.Context(), "auth:revoked_session:3")
+		assertRevoked(t, serve("Bearer "+token, JWTAuthMiddleware()))
+	})
+
+	t.Run("tokens valid after", func(t *testing.T) {
+		marker := now.Truncate(time.Second).Add(500 * time.Millisecond)
+		before := accessToken(t, "jti-before", marker.Add(-100*time.Millisecond))
+		sameSecondAfter := accessToken(t, "jti-after", marker.Add(100*time.Millisecond))
+		if err := cache.SetTokensValidAfter(7, marker); err != nil {
+			t.Fatal(err)
+		}
+
+		assertRevoked(t, serve("Bearer "+before, JWTAuthMiddleware()))
+		// The marker has millisecond precision, so a login right after a "log out everywhere" works
+		if code := serve("Bearer "+sameSecondAfter, JWTAuthMiddleware()).Code; code != http.StatusNoContent {
+			t.Errorf("token issued after the marker in the same second: status = %d, want %d", code, http.StatusNoContent)
+		}
+	})
+}
+
+func TestJWTAuthMiddlewareRejectsDisabledUser(t *testing.T) {
+	useTestKeys(t)
+	testutil.UseFakeRedis(t)
+
+	token := accessToken(t, "jti-disabled", time.Now())
+	if err := cache.SetUserDisabled(7, true); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := serve("Bearer "+token, JWTAuthMiddleware())
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusForbidden)
+	}
+	if !strings.Contains(recorder.Body.String(), utils.ErrAccountDisabled.Error()) {
+		t.Errorf("body = %s, want the account disabled error", recorder.Body)
+	}
+}
+
+func TestJWTAuthMiddlewareFailsClosedWithoutRedis(t *testing.T) {
+	useTestKeys(t)
+
+	previous := config.Redis
+	config.Redis = redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 100 * time.Millisecond})
+	t.Cleanup(func() {
+		config.Redis.Close()
+		config.Redis = previous
+	})
+
+	if code := serve("Bearer "+accessToken(t, "jti-no-redis", time.Now()), JWTAuthMiddleware()).Code; code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", code, http.StatusServiceUnavailable)
+	}
+}
+
+func assertRevoked(t *testing.T, recorder *httptest.ResponseRecorder) {
+	t.Helper()
+	if recorder.Code != http.StatusUnauthorized || !strings.Contains(recorder.Body.String(), "revoked") {
+		t.Errorf("got %d %s, want 401 Token has been revoked", recorder.Code, recorder.Body)
+	}
+}
+
+// currentClaims reads the claims the middleware stored in the context
+func currentClaims(c *gin.Context) (*utils.JWTClaims, bool) {
+	value, _ := c.Get("user")
+	claims, ok := value.(*utils.JWTClaims)
+	return claims, ok
+}
+
+func TestRequirePermission(t *testing.T) {
+	withClaims := func(claims *utils.JWTClaims) gin.HandlerFunc {
+		return func(c *gin.Context) { c.Set("user", claims) }
+	}
+
+	if code := serve("", RequirePermission(utils.PermBooksWrite)).Code; code != http.StatusUnauthorized {
+		t.Errorf("without claims: status = %d, want %d", code, http.StatusUnauthorized)
+	}
+
+	// The role is not consulted, only the permissions in the token
+	claims := &utils.JWTClaims{Role: utils.RoleAdmin, Permissions: []string{utils.PermReviewsWrite}}
+	recorder := serve("", withClaims(claims), RequirePermission(utils.PermBooksWrite))
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("missing permission: status = %d, want %d", recorder.Code, http.StatusForbidden)
+	}
+	if !strings.Contains(recorder.Body.String(), `"required_permission":"books:write"`) {
+		t.Errorf("body = %s, want the required permission", recorder.Body)
+	}
+
+	if code := serve("", withClaims(claims), RequirePermission(utils.PermReviewsWrite)).Code; code != http.StatusNoContent {
+		t.Errorf("granted permission: status = %d, want %d", code, http.StatusNoContent)
+	}
+}
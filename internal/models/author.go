@@ -1,11 +1,15 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Author struct {
 	gorm.Model
-	Name      string `json:"name"`
-	Biography string `json:"biography"`
-	BirthDate string `json:"birth_date"`
-	Books     []Book `gorm:"foreignKey:AuthorID"`
+	Name      string     `json:"name"`
+	Biography string     `json:"biography"`
+	BirthDate *time.Time `json:"birth_date" gorm:"type:date"` // nil when unknown
+	Books     []Book     `gorm:"foreignKey:AuthorID"`
 }
@@ -11,4 +11,43 @@ type Book struct {
 	Description     string   `json:"description"`
 	Author          Author   `gorm:"foreignKey:AuthorID"`
 	Reviews         []Review `gorm:"foreignKey:BookID"`
+
+	// Aggregates over the book's approved reviews. The review repository keeps them
+	// up to date in the same transaction as each review change.
+	RatingCount   int     `json:"rating_count" gorm:"not null;default:0"`
+	RatingSum     int     `json:"rating_sum" gorm:"not null;default:0"`
+	RatingAverage float64 `json:"rating_average" gorm:"not null;default:0;index"`
+	Rating1Count  int     `json:"rating1_count" gorm:"not null;default:0"`
+	Rating2Count  int     `json:"rating2_count" gorm:"not null;default:0"`
+	Rating3Count  int     `json:"rating3_count" gorm:"not null;default:0"`
+	Rating4Count  int     `json:"rating4_count" gorm:"not null;default:0"`
+	Rating5Count  int     `json:"rating5_count" gorm:"not null;default:0"`
 }
+
+// RatingColumns are the columns holding a book's rating aggregates
+var RatingColumns = []string{"RatingCount", "RatingSum", "RatingAverage", "Rating1Count", "Rating2Count", "Rating3Count", "Rating4Count", "Rating5Count"}
+
+// RecomputeRatingsSQL rebuilds the rating aggregates of every book from its reviews with the
+// status given as its only argument. It is shared by the repository and the database migration.
+const RecomputeRatingsSQL = `UPDATE books SET
+		rating_count = COALESCE(agg.count, 0),
+		rating_sum = COALESCE(agg.sum, 0),
+		rating_average = COALESCE(agg.sum::float8 / NULLIF(agg.count, 0), 0),
+		rating1_count = COALESCE(agg.r1, 0),
+		rating2_count = COALESCE(agg.r2, 0),
+		rating3_count = COALESCE(agg.r3, 0),
+		rating4_count = COALESCE(agg.r4, 0),
+		rating5_count = COALESCE(agg.r5, 0)
+	FROM books AS b
+	LEFT JOIN (
+		SELECT book_id, COUNT(*) AS count, SUM(rating) AS sum,
+			COUNT(*) FILTER (WHERE rating = 1) AS r1,
+			COUNT(*) FILTER (WHERE rating = 2) AS r2,
+			COUNT(*) FILTER (WHERE rating = 3) AS r3,
+			COUNT(*) FILTER (WHERE rating = 4) AS r4,
+			COUNT(*) FILTER (WHERE rating = 5) AS r5
+		FROM reviews
+		WHERE deleted_at IS NULL AND status = ? AND rating BETWEEN 1 AND 5
+		GROUP BY book_id
+	) AS agg ON agg.book_id = b.id
+	WHERE books.id = b.id`
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecoveryCode is a one-time code that replaces a TOTP code when the authenticator is lost.
+// Only the SHA-256 hash of the normalized code is stored.
+type RecoveryCode struct {
+	gorm.Model
+	UserID   uint       `json:"user_id" gorm:"index;not null"`
+	CodeHash string     `json:"-" gorm:"not null"`
+	UsedAt   *time.Time `json:"used_at"`
+	User     User       `json:"-" gorm:"foreignKey:UserID"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken is a server-side record of an issued refresh token.
+// Only the SHA-256 hash of the token is stored. Tokens that descend from the
+// same login share a FamilyID so the whole chain can be revoked at once.
+type RefreshToken struct {
+	gorm.Model
+	UserID       uint       `json:"user_id" gorm:"index;not null"`
+	TokenHash    string     `json:"-" gorm:"uniqueIndex;not null"`
+	FamilyID     string     `json:"family_id" gorm:"index;not null"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	ReplacedByID *uint      `json:"replaced_by_id"`
+	User         User       `json:"-" gorm:"foreignKey:UserID"`
+}
@@ -1,12 +1,37 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Review moderation states
+const (
+	ReviewPending  = "pending"  // Waiting for a moderator, only visible to its author
+	ReviewApproved = "approved" // Publicly visible
+	ReviewRejected = "rejected" // Turned down before it was published
+	ReviewHidden   = "hidden"   // Taken down after it was published
+)
 
 type Review struct {
 	gorm.Model
-	Rating     int    `json:"rating"`
-	Comment    string `json:"comment"`
-	DatePosted string `json:"date_posted"`
-	BookID     uint   `json:"book_id"`
-	Book       Book   `gorm:"foreignKey:BookID"`
+	Rating   int        `json:"rating"`
+	Comment  string     `json:"comment"`
+	PostedAt time.Time  `json:"posted_at" gorm:"not null;index"` // Set by the server when the review is created
+	EditedAt *time.Time `json:"edited_at"`                       // Last change to the rating or comment, nil if never edited
+	BookID   uint       `json:"book_id" gorm:"uniqueIndex:idx_reviews_user_book,where:deleted_at IS NULL"`
+	Book     Book       `gorm:"foreignKey:BookID"`
+	UserID   *uint      `json:"user_id" gorm:"index;uniqueIndex:idx_reviews_user_book,where:deleted_at IS NULL"` // Author of the review, nil for reviews written before accounts were tracked
+	User     User       `gorm:"foreignKey:UserID"`
+
+	// Moderation
+	Status           string     `json:"status" gorm:"not null;default:'approved';index"`
+	ModerationReason string     `json:"moderation_reason"`
+	ModeratedByID    *uint      `json:"moderated_by_id"`
+	ModeratedAt      *time.Time `json:"moderated_at"`
+
+	// Vote totals, kept in step with the review_votes table
+	HelpfulCount   int `json:"helpful_count" gorm:"not null;default:0"`
+	UnhelpfulCount int `json:"unhelpful_count" gorm:"not null;default:0"`
 }
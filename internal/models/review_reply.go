@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReviewReply is a response to a review or to another reply. Replies go through
+// the same moderation states as reviews.
+type ReviewReply struct {
+	gorm.Model
+	ReviewID uint   `json:"review_id" gorm:"not null;index"`
+	ParentID *uint  `json:"parent_id" gorm:"index"` // Reply this one answers, nil for a direct reply to the review
+	Depth    int    `json:"depth" gorm:"not null"`  // 1 for direct replies to the review
+	UserID   uint   `json:"user_id" gorm:"not null;index"`
+	Comment  string `json:"comment" gorm:"not null"`
+	Review   Review `json:"-" gorm:"foreignKey:ReviewID"`
+	User     User   `json:"-" gorm:"foreignKey:UserID"`
+
+	// Moderation
+	Status           string     `json:"status" gorm:"not null;default:'approved';index"`
+	ModerationReason string     `json:"moderation_reason"`
+	ModeratedByID    *uint      `json:"moderated_by_id"`
+	ModeratedAt      *time.Time `json:"moderated_at"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Reasons a review can be reported for
+const (
+	ReportSpam       = "spam"
+	ReportAbuse      = "abuse"
+	ReportOffensive  = "offensive"
+	ReportSpoiler    = "spoiler"
+	ReportOffTopic   = "off_topic"
+	ReportOtherIssue = "other"
+)
+
+// Outcomes of resolving the reports of a review
+const (
+	ReportDismissed = "dismissed" // The review was fine and stays (or becomes) visible
+	ReportUpheld    = "upheld"    // The review was hidden
+	ReportObsolete  = "obsolete"  // The review was deleted before a moderator got to it
+)
+
+// ReviewReport is a user's complaint about a review. A user can report a review once.
+type ReviewReport struct {
+	gorm.Model
+	ReviewID     uint       `json:"review_id" gorm:"not null;uniqueIndex:idx_review_reports_review_user"`
+	UserID       uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_review_reports_review_user"`
+	Category     string     `json:"category" gorm:"not null"`
+	Details      string     `json:"details"`
+	ResolvedAt   *time.Time `json:"resolved_at" gorm:"index"` // Nil while the report is open
+	ResolvedByID *uint      `json:"resolved_by_id"`
+	Resolution   string     `json:"resolution"`
+	Review       Review     `json:"-" gorm:"foreignKey:ReviewID"`
+	User         User       `json:"-" gorm:"foreignKey:UserID"`
+}
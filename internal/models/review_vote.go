@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// ReviewVote is one user's verdict on whether a review was helpful.
+// Each user has at most one vote per review and can change or withdraw it.
+type ReviewVote struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ReviewID  uint      `json:"review_id" gorm:"not null;uniqueIndex:idx_review_votes_review_user"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_review_votes_review_user;index"`
+	Helpful   bool      `json:"helpful"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Review    Review    `json:"-" gorm:"foreignKey:ReviewID"`
+	User      User      `json:"-" gorm:"foreignKey:UserID"`
+}
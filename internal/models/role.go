@@ -0,0 +1,27 @@
+package models
+
+import "gorm.io/gorm"
+
+// Role groups permissions that can be assigned to users
+type Role struct {
+	gorm.Model
+	Name        string       `json:"name" gorm:"uniqueIndex;not null"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions" gorm:"many2many:role_permissions"`
+}
+
+// Permission is a single capability such as "books:write"
+type Permission struct {
+	gorm.Model
+	Name        string `json:"name" gorm:"uniqueIndex;not null"`
+	Description string `json:"description"`
+}
+
+// PermissionNames returns the names of all permissions granted by the role
+func (r Role) PermissionNames() []string {
+	names := make([]string, 0, len(r.Permissions))
+	for _, permission := range r.Permissions {
+		names = append(names, permission.Name)
+	}
+	return names
+}
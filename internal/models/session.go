@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Session is one login on one device. It follows a refresh token family:
+// rotating the refresh token keeps the session, revoking the family ends it.
+type Session struct {
+	gorm.Model
+	UserID      uint       `json:"user_id" gorm:"index;not null"`
+	FamilyID    string     `json:"-" gorm:"uniqueIndex;not null"`
+	DeviceLabel string     `json:"device_label"`
+	UserAgent   string     `json:"user_agent"`
+	IPAddress   string     `json:"ip_address"`
+	LastSeenAt  time.Time  `json:"last_seen_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	User        User       `json:"-" gorm:"foreignKey:UserID"`
+}
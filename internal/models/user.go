@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -11,6 +13,27 @@ type User struct {
 	Username string `json:"username" gorm:"unique;not null"`
 	Password string `json:"-"`
 	Email    string `json:"email" gorm:"unique;not null"`
-	Role     string `json:"role"` // Admin, User
+	RoleID   *uint  `json:"role_id" gorm:"index"`
+	Role     Role   `json:"role" gorm:"foreignKey:RoleID"`
+
+	EmailVerified   bool       `json:"email_verified" gorm:"not null;default:false"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+
+	// Identity at the OpenID Connect provider, for users who signed in through it
+	OIDCIssuer  *string `json:"-" gorm:"uniqueIndex:idx_users_oidc_identity"`
+	OIDCSubject *string `json:"-" gorm:"uniqueIndex:idx_users_oidc_identity"`
+
+	// Two-factor authentication. TOTPSecret is set when enrolment starts and
+	// only counts once TOTPEnabled is true.
+	TOTPSecret    string     `json:"-"`
+	TOTPEnabled   bool       `json:"totp_enabled" gorm:"not null;default:false"`
+	TOTPEnabledAt *time.Time `json:"totp_enabled_at"`
+
+	IsActive      bool       `json:"is_active" gorm:"not null;default:true"`
+	DeactivatedAt *time.Time `json:"deactivated_at"`
 
+	// Display preferences
+	DisplayName string `json:"display_name"`
+	Locale      string `json:"locale" gorm:"not null;default:'en'"`
+	Timezone    string `json:"timezone" gorm:"not null;default:'UTC'"`
 }
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"mentalartsapi/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository struct {
+	DB *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) *APIKeyRepository {
+	return &APIKeyRepository{DB: db}
+}
+
+func (r *APIKeyRepository) CreateKey(key *models.APIKey) error {
+	return r.DB.Create(key).Error
+}
+
+func (r *APIKeyRepository) GetKeysForUser(userID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.DB.Where("user_id = ?", userID).Order("id").Find(&keys).Error
+	return keys, err
+}
+
+// GetKeyByHash returns a key together with its owner and the owner's permissions
+func (r *APIKeyRepository) GetKeyByHash(hash string) (models.APIKey, error) {
+	var key models.APIKey
+	err := r.DB.Preload("User.Role.Permissions").Where("key_hash = ?", hash).First(&key).Error
+	return key, err
+}
+
+// RevokeKey revokes a key of the given user. It returns false if no such active key exists.
+func (r *APIKeyRepository) RevokeKey(id, userID uint) (bool, error) {
+	result := r.DB.Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+// RevokeAllForUser revokes every active key of the user
+func (r *APIKeyRepository) RevokeAllForUser(userID uint) error {
+	return r.DB.Model(&models.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *APIKeyRepository) TouchKey(id uint, usedAt time.Time) error {
+	return r.DB.Model(&models.APIKey{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
@@ -1,17 +1,42 @@
 package repository
 
 import (
+	"fmt"
 	"mentalartsapi/config"
 	"mentalartsapi/internal/models"
+	"strings"
 )
 
 // BookRepository interface for book repository
 type BookRepository interface {
-	GetAllBooks() ([]models.Book, error)
+	ListBooks(filter BookFilter) ([]models.Book, int64, error)
 	GetBookByID(id uint) (models.Book, error)
 	CreateBook(book *models.Book) error
 	UpdateBook(book *models.Book) error
 	DeleteBook(id uint) error
+	RecomputeRatings() (int64, error)
+}
+
+// BookFilter narrows down, orders and pages a book listing. Zero values do not filter.
+type BookFilter struct {
+	AuthorID  uint
+	YearFrom  int
+	YearTo    int
+	ISBN      string // Digits only
+	Title     string // Part of the title, matched case-insensitively
+	MinRating float64
+
+	SortColumn string // Trusted column name; ties are broken by ID in the same direction
+	Desc       bool
+	After      *BookKey // Keyset position: only books after it are listed and Offset is ignored
+	Offset     int
+	Limit      int
+}
+
+// BookKey is the position of a book in a listing: its sort column value and ID
+type BookKey struct {
+	Value interface{}
+	ID    uint
 }
 
 type bookRepo struct{}
@@ -21,15 +46,61 @@ func NewBookRepository() BookRepository {
 	return &bookRepo{}
 }
 
-func (r *bookRepo) GetAllBooks() ([]models.Book, error) {
+// ListBooks returns one page of the books matching the filter with their authors,
+// and the number of matching books on all pages
+func (r *bookRepo) ListBooks(filter BookFilter) ([]models.Book, int64, error) {
+	query := config.DB.Model(&models.Book{})
+	if filter.AuthorID != 0 {
+		query = query.Where("author_id = ?", filter.AuthorID)
+	}
+	if filter.YearFrom != 0 {
+		query = query.Where("publication_year >= ?", filter.YearFrom)
+	}
+	if filter.YearTo != 0 {
+		query = query.Where("publication_year <= ?", filter.YearTo)
+	}
+	if filter.ISBN != "" {
+		// Books saved before ISBNs were normalised may still contain hyphens or spaces
+		query = query.Where("REPLACE(REPLACE(isbn, '-', ''), ' ', '') = ?", filter.ISBN)
+	}
+	if filter.Title != "" {
+		query = query.Where("title ILIKE ?", "%"+escapeLike(filter.Title)+"%")
+	}
+	if filter.MinRating > 0 {
+		query = query.Where("rating_average >= ?", filter.MinRating)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	direction, comparison := "ASC", ">"
+	if filter.Desc {
+		direction, comparison = "DESC", "<"
+	}
+	if filter.After != nil {
+		query = query.Where(fmt.Sprintf("(%s, id) %s (?, ?)", filter.SortColumn, comparison), filter.After.Value, filter.After.ID)
+	} else {
+		query = query.Offset(filter.Offset)
+	}
+
 	var books []models.Book
-	err := config.DB.Preload("Author").Preload("Reviews").Find(&books).Error
-	return books, err
+	err := query.Preload("Author").
+		Order(fmt.Sprintf("%s %s, id %s", filter.SortColumn, direction, direction)).
+		Limit(filter.Limit).
+		Find(&books).Error
+	return books, total, err
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern so the text matches literally
+func escapeLike(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(text)
 }
 
 func (r *bookRepo) GetBookByID(id uint) (models.Book, error) {
 	var book models.Book
-	err := config.DB.Preload("Author").Preload("Reviews").First(&book, id).Error
+	err := config.DB.Preload("Author").First(&book, id).Error
 	return book, err
 }
 
@@ -37,10 +108,18 @@ func (r *bookRepo) CreateBook(book *models.Book) error {
 	return config.DB.Create(book).Error
 }
 
+// UpdateBook saves a book. The rating aggregates are owned by the review repository and left alone.
 func (r *bookRepo) UpdateBook(book *models.Book) error {
-	return config.DB.Save(book).Error
+	return config.DB.Omit(models.RatingColumns...).Save(book).Error
 }
 
 func (r *bookRepo) DeleteBook(id uint) error {
 	return config.DB.Delete(&models.Book{}, id).Error
 }
+
+// RecomputeRatings rebuilds the rating aggregates of every book from its approved reviews
+// and returns the number of books updated
+func (r *bookRepo) RecomputeRatings() (int64, error) {
+	result := config.DB.Exec(models.RecomputeRatingsSQL, models.ReviewApproved)
+	return result.RowsAffected, result.Error
+}
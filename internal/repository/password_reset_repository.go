@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"mentalartsapi/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PasswordResetRepository struct {
+	DB *gorm.DB
+}
+
+func NewPasswordResetRepository(db *gorm.DB) *PasswordResetRepository {
+	return &PasswordResetRepository{DB: db}
+}
+
+func (r *PasswordResetRepository) CreateToken(token *models.PasswordResetToken) error {
+	return r.DB.Create(token).Error
+}
+
+func (r *PasswordResetRepository) GetTokenByHash(hash string) (models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	err := r.DB.Where("token_hash = ?", hash).First(&token).Error
+	return token, err
+}
+
+// MarkTokenUsed consumes a token. It returns false if the token had already been used.
+func (r *PasswordResetRepository) MarkTokenUsed(id uint) (bool, error) {
+	result := r.DB.Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+// InvalidateTokensForUser consumes every outstanding reset token of a user
+func (r *PasswordResetRepository) InvalidateTokensForUser(userID uint) error {
+	return r.DB.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
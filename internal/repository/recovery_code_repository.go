@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"mentalartsapi/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type RecoveryCodeRepository struct {
+	DB *gorm.DB
+}
+
+func NewRecoveryCodeRepository(db *gorm.DB) *RecoveryCodeRepository {
+	return &RecoveryCodeRepository{DB: db}
+}
+
+// ReplaceCodes deletes every recovery code of the user and stores the given ones
+func (r *RecoveryCodeRepository) ReplaceCodes(userID uint, codes []models.RecoveryCode) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// UseCode marks an unused code as used. It returns false when no such code exists.
+func (r *RecoveryCodeRepository) UseCode(userID uint, hash string) (bool, error) {
+	result := r.DB.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+// CountUnused returns how many recovery codes the user has left
+func (r *RecoveryCodeRepository) CountUnused(userID uint) (int64, error) {
+	var count int64
+	err := r.DB.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// DeleteForUser removes every recovery code of the user
+func (r *RecoveryCodeRepository) DeleteForUser(userID uint) error {
+	return r.DB.Unscoped().Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+}
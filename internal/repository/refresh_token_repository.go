@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"mentalartsapi/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type RefreshTokenRepository struct {
+	DB *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{DB: db}
+}
+
+func (r *RefreshTokenRepository) CreateToken(token *models.RefreshToken) error {
+	return r.DB.Create(token).Error
+}
+
+func (r *RefreshTokenRepository) GetTokenByHash(hash string) (models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.DB.Where("token_hash = ?", hash).First(&token).Error
+	return token, err
+}
+
+// RotateToken atomically revokes the old token and stores its replacement.
+// It returns false without creating anything when the old token was already revoked,
+// which means the same refresh token has been presented twice.
+func (r *RefreshTokenRepository) RotateToken(old *models.RefreshToken, replacement *models.RefreshToken) (bool, error) {
+	rotated := false
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", old.ID).
+			Update("revoked_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := tx.Create(replacement).Error; err != nil {
+			return err
+		}
+		rotated = true
+		return tx.Model(&models.RefreshToken{}).Where("id = ?", old.ID).Update("replaced_by_id", replacement.ID).Error
+	})
+	return rotated, err
+}
+
+// RevokeFamily revokes every token of a refresh token family and ends its session
+func (r *RefreshTokenRepository) RevokeFamily(familyID string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", familyID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Session{}).
+			Where("family_id = ? AND revoked_at IS NULL", familyID).
+			Update("revoked_at", now).Error
+	})
+}
+
+// RevokeAllForUser revokes every refresh token of a user and ends all of their sessions
+func (r *RefreshTokenRepository) RevokeAllForUser(userID uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Session{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error
+	})
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"mentalartsapi/config"
+	"mentalartsapi/internal/models"
+)
+
+// ReviewReplyRepository interface for review reply repository
+type ReviewReplyRepository interface {
+	GetRepliesForReview(reviewID uint) ([]models.ReviewReply, error)
+	GetFirstReplies(reviewIDs []uint, limit int) ([]models.ReviewReply, error)
+	CountReplies(column string, ids []uint) (map[uint]int, error)
+	GetRepliesByStatus(status string, page, pageSize int) ([]models.ReviewReply, int64, error)
+	GetReplyByID(id uint) (models.ReviewReply, error)
+	GetRepliesByUser(userID uint) ([]models.ReviewReply, error)
+	CreateReply(reply *models.ReviewReply) error
+	UpdateReply(reply *models.ReviewReply) error
+	DeleteReplies(ids []uint) error
+}
+
+type reviewReplyRepo struct{}
+
+// NewReviewReplyRepository creates a new review reply repository
+func NewReviewReplyRepository() ReviewReplyRepository {
+	return &reviewReplyRepo{}
+}
+
+// GetRepliesForReview returns every reply of a review in any state, oldest first
+func (r *reviewReplyRepo) GetRepliesForReview(reviewID uint) ([]models.ReviewReply, error) {
+	var replies []models.ReviewReply
+	err := config.DB.Preload("User").Where("review_id = ?", reviewID).Order("created_at, id").Find(&replies).Error
+	return replies, err
+}
+
+// GetFirstReplies returns up to limit published direct replies of each review, oldest first
+func (r *reviewReplyRepo) GetFirstReplies(reviewIDs []uint, limit int) ([]models.ReviewReply, error) {
+	ranked := config.DB.Model(&models.ReviewReply{}).
+		Select("*, ROW_NUMBER() OVER (PARTITION BY review_id ORDER BY created_at, id) AS position").
+		Where("review_id IN ? AND parent_id IS NULL AND status = ?", reviewIDs, models.ReviewApproved)
+
+	var replies []models.ReviewReply
+	err := config.DB.Preload("User").Table("(?) AS review_replies", ranked).
+		Where("position <= ?", limit).
+		Order("created_at, id").
+		Find(&replies).Error
+	return replies, err
+}
+
+// CountReplies counts the published replies per review_id or parent_id, as chosen by column
+func (r *reviewReplyRepo) CountReplies(column string, ids []uint) (map[uint]int, error) {
+	var rows []struct {
+		ID    uint
+		Count int
+	}
+	query := config.DB.Model(&models.ReviewReply{}).
+		Select(column+" AS id, COUNT(*) AS count").
+		Where(column+" IN ? AND status = ?", ids, models.ReviewApproved)
+	if column == "review_id" {
+		query = query.Where("parent_id IS NULL")
+	}
+	if err := query.Group(column).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		counts[row.ID] = row.Count
+	}
+	return counts, nil
+}
+
+// GetRepliesByStatus returns one page of replies with the given status, oldest first
+func (r *reviewReplyRepo) GetRepliesByStatus(status string, page, pageSize int) ([]models.ReviewReply, int64, error) {
+	query := config.DB.Model(&models.ReviewReply{}).Where("status = ?", status)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var replies []models.ReviewReply
+	err := query.Preload("User").Order("updated_at, id").Offset((page - 1) * pageSize).Limit(pageSize).Find(&replies).Error
+	return replies, total, err
+}
+
+func (r *reviewReplyRepo) GetReplyByID(id uint) (models.ReviewReply, error) {
+	var reply models.ReviewReply
+	err := config.DB.Preload("User").First(&reply, id).Error
+	return reply, err
+}
+
+// GetRepliesByUser returns every reply a user wrote, in any state
+func (r *reviewReplyRepo) GetRepliesByUser(userID uint) ([]models.ReviewReply, error) {
+	var replies []models.ReviewReply
+	err := config.DB.Where("user_id = ?", userID).Find(&replies).Error
+	return replies, err
+}
+
+func (r *reviewReplyRepo) CreateReply(reply *models.ReviewReply) error {
+	return config.DB.Create(reply).Error
+}
+
+func (r *reviewReplyRepo) UpdateReply(reply *models.ReviewReply) error {
+	return config.DB.Omit("User", "Review").Save(reply).Error
+}
+
+func (r *reviewReplyRepo) DeleteReplies(ids []uint) error {
+	return config.DB.Delete(&models.ReviewReply{}, ids).Error
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"mentalartsapi/config"
+	"mentalartsapi/internal/models"
+	"time"
+)
+
+// ReviewReportRepository interface for review report repository
+type ReviewReportRepository interface {
+	CreateReport(report *models.ReviewReport) error
+	HasReported(reviewID, userID uint) (bool, error)
+	CountOpenReports(reviewID uint) (int64, error)
+	GetReportedReviewIDs(page, pageSize int) ([]uint, int64, error)
+	GetOpenReports(reviewIDs []uint) ([]models.ReviewReport, error)
+	ResolveReports(reviewID, moderatorID uint, resolution string) (int64, error)
+}
+
+type reviewReportRepo struct{}
+
+// NewReviewReportRepository creates a new review report repository
+func NewReviewReportRepository() ReviewReportRepository {
+	return &reviewReportRepo{}
+}
+
+func (r *reviewReportRepo) CreateReport(report *models.ReviewReport) error {
+	return config.DB.Create(report).Error
+}
+
+// HasReported reports whether the user has ever reported the review
+func (r *reviewReportRepo) HasReported(reviewID, userID uint) (bool, error) {
+	var count int64
+	err := config.DB.Model(&models.ReviewReport{}).Where("review_id = ? AND user_id = ?", reviewID, userID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *reviewReportRepo) CountOpenReports(reviewID uint) (int64, error) {
+	var count int64
+	err := config.DB.Model(&models.ReviewReport{}).Where("review_id = ? AND resolved_at IS NULL", reviewID).Count(&count).Error
+	return count, err
+}
+
+// GetReportedReviewIDs returns one page of reviews with open reports, most reported first
+func (r *reviewReportRepo) GetReportedReviewIDs(page, pageSize int) ([]uint, int64, error) {
+	var total int64
+	if err := config.DB.Model(&models.ReviewReport{}).Where("resolved_at IS NULL").Distinct("review_id").Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var ids []uint
+	err := config.DB.Model(&models.ReviewReport{}).
+		Where("resolved_at IS NULL").
+		Group("review_id").
+		Order("COUNT(*) DESC, MIN(created_at)").
+		Offset((page-1)*pageSize).
+		Limit(pageSize).
+		Pluck("review_id", &ids).Error
+	return ids, total, err
+}
+
+// GetOpenReports returns the open reports of the given reviews with their reviews and reporters, oldest first
+func (r *reviewReportRepo) GetOpenReports(reviewIDs []uint) ([]models.ReviewReport, error) {
+	var reports []models.ReviewReport
+	err := config.DB.Preload("Review.Book").Preload("Review.User").Preload("User").
+		Where("review_id IN ? AND resolved_at IS NULL", reviewIDs).
+		Order("created_at").
+		Find(&reports).Error
+	return reports, err
+}
+
+// ResolveReports closes all open reports of a review and returns how many there were
+func (r *reviewReportRepo) ResolveReports(reviewID, moderatorID uint, resolution string) (int64, error) {
+	result := config.DB.Model(&models.ReviewReport{}).
+		Where("review_id = ? AND resolved_at IS NULL", reviewID).
+		Updates(map[string]interface{}{"resolved_at": time.Now(), "resolved_by_id": moderatorID, "resolution": resolution})
+	return result.RowsAffected, result.Error
+}
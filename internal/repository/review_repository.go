@@ -1,17 +1,27 @@
 package repository
 
 import (
+	"fmt"
 	"mentalartsapi/config"
 	"mentalartsapi/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ReviewRepository interface for review repository
 type ReviewRepository interface {
-	GetReviewsForBook(bookID uint) ([]models.Review, error)
+	GetReviewsForBook(bookID uint, status string) ([]models.Review, error)
+	GetReviewsByUser(userID uint, status string) ([]models.Review, error)
+	GetReviewsByStatus(status string, page, pageSize int) ([]models.Review, int64, error)
 	GetReviewByID(id uint) (models.Review, error)
+	GetUserReviewForBook(bookID, userID uint) (models.Review, error)
 	CreateReview(review *models.Review) error
 	UpdateReview(review *models.Review) error
 	DeleteReview(id uint) error
+	SetVote(reviewID, userID uint, helpful bool) error
+	DeleteVote(reviewID, userID uint) error
 }
 
 type reviewRepo struct{}
@@ -21,32 +31,196 @@ func NewReviewRepository() ReviewRepository {
 	return &reviewRepo{}
 }
 
-func (r *reviewRepo) GetReviewsForBook(bookID uint) ([]models.Review, error) {
+// GetReviewsForBook returns the reviews of a book with the given status, or all of them for an empty status
+func (r *reviewRepo) GetReviewsForBook(bookID uint, status string) ([]models.Review, error) {
 	var reviews []models.Review
-	err := config.DB.Preload("Book").Where("book_id = ?", bookID).Find(&reviews).Error
+	query := config.DB.Preload("Book").Preload("User").Where("book_id = ?", bookID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&reviews).Error
 	if err != nil {
 		return nil, err
 	}
 	return reviews, nil
 }
 
+// GetReviewsByUser returns a user's reviews with the given status, or all of them for an empty status
+func (r *reviewRepo) GetReviewsByUser(userID uint, status string) ([]models.Review, error) {
+	var reviews []models.Review
+	query := config.DB.Preload("Book").Preload("User").Where("user_id = ?", userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("posted_at DESC, id DESC").Find(&reviews).Error
+	if err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+// GetReviewsByStatus returns one page of reviews with the given status, oldest first
+func (r *reviewRepo) GetReviewsByStatus(status string, page, pageSize int) ([]models.Review, int64, error) {
+	query := config.DB.Model(&models.Review{}).Where("status = ?", status)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var reviews []models.Review
+	err := query.Preload("Book").Preload("User").Order("updated_at, id").Offset((page - 1) * pageSize).Limit(pageSize).Find(&reviews).Error
+	return reviews, total, err
+}
+
 func (r *reviewRepo) GetReviewByID(id uint) (models.Review, error) {
 	var review models.Review
-	err := config.DB.Preload("Book").First(&review, id).Error
+	err := config.DB.Preload("Book").Preload("User").First(&review, id).Error
+	if err != nil {
+		return models.Review{}, err
+	}
+	return review, nil
+}
+
+func (r *reviewRepo) GetUserReviewForBook(bookID, userID uint) (models.Review, error) {
+	var review models.Review
+	err := config.DB.Preload("Book").Preload("User").Where("book_id = ? AND user_id = ?", bookID, userID).First(&review).Error
 	if err != nil {
 		return models.Review{}, err
 	}
 	return review, nil
 }
 
+// CreateReview stores a new review and adds it to its book's rating aggregates
 func (r *reviewRepo) CreateReview(review *models.Review) error {
-	return config.DB.Create(review).Error
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(review).Error; err != nil {
+			return err
+		}
+		return applyRating(tx, review.BookID, 0, countedRating(*review))
+	})
 }
 
+// UpdateReview saves a review and moves its book's rating aggregates along with any
+// change of rating or status. The vote totals are left alone; only SetVote and DeleteVote change them.
 func (r *reviewRepo) UpdateReview(review *models.Review) error {
-	return config.DB.Save(review).Error
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		old, err := lockReview(tx, review.ID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations, "HelpfulCount", "UnhelpfulCount").Save(review).Error; err != nil {
+			return err
+		}
+		return applyRating(tx, old.BookID, countedRating(old), countedRating(*review))
+	})
 }
 
+// DeleteReview deletes a review, closes its open reports and removes it from its book's rating aggregates
 func (r *reviewRepo) DeleteReview(id uint) error {
-	return config.DB.Delete(&models.Review{}, id).Error
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		old, err := lockReview(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Review{}, id).Error; err != nil {
+			return err
+		}
+		// Nothing is left to moderate, so the review's open reports leave the queue
+		if err := tx.Model(&models.ReviewReport{}).
+			Where("review_id = ? AND resolved_at IS NULL", id).
+			Updates(map[string]interface{}{"resolved_at": time.Now(), "resolution": models.ReportObsolete}).Error; err != nil {
+			return err
+		}
+		return applyRating(tx, old.BookID, countedRating(old), 0)
+	})
+}
+
+// lockReview reads the stored state of a review and locks it until the transaction ends
+func lockReview(tx *gorm.DB, id uint) (models.Review, error) {
+	var review models.Review
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "book_id", "rating", "status").First(&review, id).Error
+	return review, err
+}
+
+// countedRating returns the rating a review contributes to its book's aggregates, 0 if it is not published
+func countedRating(review models.Review) int {
+	if review.Status != models.ReviewApproved || review.Rating < 1 || review.Rating > 5 {
+		return 0
+	}
+	return review.Rating
+}
+
+// applyRating replaces oldRating with newRating in a book's aggregates. 0 stands for no rating,
+// so (0, r) adds a rating and (r, 0) removes one.
+func applyRating(tx *gorm.DB, bookID uint, oldRating, newRating int) error {
+	if oldRating == newRating {
+		return nil
+	}
+
+	count, sum := 0, newRating-oldRating
+	updates := map[string]interface{}{}
+	if oldRating > 0 {
+		count--
+		column := fmt.Sprintf("rating%d_count", oldRating)
+		updates[column] = gorm.Expr(column + " - 1")
+	}
+	if newRating > 0 {
+		count++
+		column := fmt.Sprintf("rating%d_count", newRating)
+		updates[column] = gorm.Expr(column + " + 1")
+	}
+	updates["rating_count"] = gorm.Expr("rating_count + ?", count)
+	updates["rating_sum"] = gorm.Expr("rating_sum + ?", sum)
+	updates["rating_average"] = gorm.Expr("CASE WHEN rating_count + ? > 0 THEN (rating_sum + ?)::float8 / (rating_count + ?) ELSE 0 END", count, sum, count)
+
+	return tx.Model(&models.Book{}).Where("id = ?", bookID).UpdateColumns(updates).Error
+}
+
+// SetVote records or changes a user's vote on a review and updates the review's totals in the same transaction.
+// The vote is upserted in one statement so that concurrent first votes of a user cannot both insert.
+func (r *reviewRepo) SetVote(reviewID, userID uint, helpful bool) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		// No row comes back when the vote is unchanged; xmax is 0 for an inserted row
+		var changed []struct{ Inserted bool }
+		now := time.Now()
+		err := tx.Raw(`INSERT INTO review_votes (review_id, user_id, helpful, created_at, updated_at) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (review_id, user_id) DO UPDATE SET helpful = EXCLUDED.helpful, updated_at = EXCLUDED.updated_at
+			WHERE review_votes.helpful <> EXCLUDED.helpful
+			RETURNING xmax = 0 AS inserted`, reviewID, userID, helpful, now, now).Scan(&changed).Error
+		if err != nil || len(changed) == 0 {
+			return err
+		}
+
+		if !changed[0].Inserted {
+			if err := adjustVoteCount(tx, reviewID, !helpful, -1); err != nil {
+				return err
+			}
+		}
+		return adjustVoteCount(tx, reviewID, helpful, 1)
+	})
+}
+
+// DeleteVote withdraws a user's vote on a review and updates the review's totals in the same transaction
+func (r *reviewRepo) DeleteVote(reviewID, userID uint) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var vote models.ReviewVote
+		err := tx.Clauses(clause.Returning{}).Where("review_id = ? AND user_id = ?", reviewID, userID).Delete(&vote).Error
+		if err != nil {
+			return err
+		}
+		if vote.ID == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return adjustVoteCount(tx, reviewID, vote.Helpful, -1)
+	})
+}
+
+// adjustVoteCount adds delta to the helpful or unhelpful total of a review without touching updated_at
+func adjustVoteCount(tx *gorm.DB, reviewID uint, helpful bool, delta int) error {
+	column := "unhelpful_count"
+	if helpful {
+		column = "helpful_count"
+	}
+	return tx.Model(&models.Review{}).Where("id = ?", reviewID).UpdateColumn(column, gorm.Expr(column+" + ?", delta)).Error
 }
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"mentalartsapi/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type RoleRepository struct {
+	DB *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) *RoleRepository {
+	return &RoleRepository{DB: db}
+}
+
+func (r *RoleRepository) GetRoleByName(name string) (models.Role, error) {
+	var role models.Role
+	err := r.DB.Preload("Permissions").Where("name = ?", name).First(&role).Error
+	return role, err
+}
+
+func (r *RoleRepository) GetRoleByID(id uint) (models.Role, error) {
+	var role models.Role
+	err := r.DB.Preload("Permissions").First(&role, id).Error
+	return role, err
+}
+
+func (r *RoleRepository) GetAllRoles() ([]models.Role, error) {
+	var roles []models.Role
+	err := r.DB.Preload("Permissions").Order("id").Find(&roles).Error
+	return roles, err
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"mentalartsapi/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SessionRepository struct {
+	DB *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) *SessionRepository {
+	return &SessionRepository{DB: db}
+}
+
+func (r *SessionRepository) CreateSession(session *models.Session) error {
+	return r.DB.Create(session).Error
+}
+
+func (r *SessionRepository) GetSessionByFamily(familyID string) (models.Session, error) {
+	var session models.Session
+	err := r.DB.Where("family_id = ?", familyID).First(&session).Error
+	return session, err
+}
+
+// GetActiveSession returns a session of the user that has not been revoked or expired
+func (r *SessionRepository) GetActiveSession(id, userID uint) (models.Session, error) {
+	var session models.Session
+	err := r.DB.Where("id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?", id, userID, time.Now()).
+		First(&session).Error
+	return session, err
+}
+
+// ListActiveForUser returns the sessions of a user that have not been revoked or expired, most recently used first
+func (r *SessionRepository) ListActiveForUser(userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+// TouchSession records that the session was used again from the given address and client
+func (r *SessionRepository) TouchSession(id uint, ipAddress, userAgent string, expiresAt time.Time) error {
+	return r.DB.Model(&models.Session{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"ip_address":   ipAddress,
+		"user_agent":   userAgent,
+		"last_seen_at": time.Now(),
+		"expires_at":   expiresAt,
+	}).Error
+}
@@ -2,8 +2,10 @@ package repository
 
 import (
 	"mentalartsapi/internal/models"
+	"strings"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository struct {
@@ -15,17 +17,101 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 }
 
 func (r *UserRepository) CreateUser(user *models.User) error {
-	return r.DB.Create(user).Error
+	return r.DB.Omit(clause.Associations).Create(user).Error
 }
 
+// GetUserByEmail returns the user with the given email address, ignoring case
+// like the uniqueness checks do
 func (r *UserRepository) GetUserByEmail(email string) (models.User, error) {
 	var user models.User
-	err := r.DB.Where("email = ?", email).First(&user).Error
+	err := r.DB.Preload("Role.Permissions").Where("LOWER(email) = LOWER(?)", email).First(&user).Error
 	return user, err
 }
 
 func (r *UserRepository) GetUserByID(id uint) (models.User, error) {
 	var user models.User
-	err := r.DB.First(&user, id).Error
+	err := r.DB.Preload("Role.Permissions").First(&user, id).Error
+	return user, err
+}
+
+// UpdateUser saves the user's own columns. The preloaded role is never written back.
+func (r *UserRepository) UpdateUser(user *models.User) error {
+	return r.DB.Omit(clause.Associations).Save(user).Error
+}
+
+// UpdatePassword replaces only the password hash of a user
+func (r *UserRepository) UpdatePassword(id uint, hashed string) error {
+	return r.DB.Model(&models.User{}).Where("id = ?", id).Update("password", hashed).Error
+}
+
+// UpdateTwoFactor saves only the two-factor columns of the user, so concurrent
+// changes to the rest of the row are not overwritten
+func (r *UserRepository) UpdateTwoFactor(user *models.User) error {
+	return r.DB.Model(user).Select("totp_secret", "totp_enabled", "totp_enabled_at").Updates(user).Error
+}
+
+// ListUsers returns one page of users, optionally filtered by a username or email search, and the total match count
+func (r *UserRepository) ListUsers(page, pageSize int, search string) ([]models.User, int64, error) {
+	query := r.DB.Model(&models.User{})
+	if search != "" {
+		pattern := "%" + escapeLike(strings.ToLower(search)) + "%"
+		query = query.Where(`LOWER(username) LIKE ? ESCAPE '\' OR LOWER(email) LIKE ? ESCAPE '\'`, pattern, pattern)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []models.User
+	err := query.Preload("Role").Order("id").Offset((page - 1) * pageSize).Limit(pageSize).Find(&users).Error
+	return users, total, err
+}
+
+func (r *UserRepository) DeleteUser(id uint) error {
+	return r.DB.Delete(&models.User{}, id).Error
+}
+
+// GetUserByUsername returns the user with the given username
+func (r *UserRepository) GetUserByUsername(username string) (models.User, error) {
+	var user models.User
+	err := r.DB.Where("username = ?", username).First(&user).Error
+	return user, err
+}
+
+// UsernameTaken reports whether a user other than exceptID holds the username, ignoring case.
+// Soft-deleted users are included because their rows still hold the unique index.
+func (r *UserRepository) UsernameTaken(username string, exceptID uint) (bool, error) {
+	return r.isTaken("username", username, exceptID)
+}
+
+// EmailTaken reports whether a user other than exceptID holds the email address, ignoring case.
+// Soft-deleted users are included because their rows still hold the unique index.
+func (r *UserRepository) EmailTaken(email string, exceptID uint) (bool, error) {
+	return r.isTaken("email", email, exceptID)
+}
+
+// OIDCIdentityTaken reports whether any user, including soft-deleted ones, is linked to the provider identity
+func (r *UserRepository) OIDCIdentityTaken(issuer, subject string) (bool, error) {
+	var count int64
+	err := r.DB.Unscoped().Model(&models.User{}).
+		Where("oidc_issuer = ? AND oidc_subject = ?", issuer, subject).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// isTaken counts the users whose column matches value. column is never user input.
+func (r *UserRepository) isTaken(column, value string, exceptID uint) (bool, error) {
+	var count int64
+	err := r.DB.Unscoped().Model(&models.User{}).
+		Where("LOWER("+column+") = LOWER(?) AND id <> ?", value, exceptID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetUserByOIDCIdentity returns the user linked to the given OpenID Connect issuer and subject
+func (r *UserRepository) GetUserByOIDCIdentity(issuer, subject string) (models.User, error) {
+	var user models.User
+	err := r.DB.Preload("Role.Permissions").Where("oidc_issuer = ? AND oidc_subject = ?", issuer, subject).First(&user).Error
 	return user, err
 }
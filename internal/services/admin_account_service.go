@@ -0,0 +1,114 @@
+package services
+
+import (
+	"mentalartsapi/config"
+	"mentalartsapi/internal/cache"
+	"mentalartsapi/internal/models"
+	"mentalartsapi/internal/utils"
+	"net/mail"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CreateAdmin creates a new, verified account with the admin role
+func (s *UserService) CreateAdmin(username, email, password string) (models.User, error) {
+	if err := validateAdminCredentials(username, email, password); err != nil {
+		return models.User{}, err
+	}
+
+	if err := ensureUnique(s.Repo.UsernameTaken, username, 0, utils.ErrUsernameTaken); err != nil {
+		return models.User{}, err
+	}
+	if err := ensureUnique(s.Repo.EmailTaken, email, 0, utils.ErrEmailTaken); err != nil {
+		return models.User{}, err
+	}
+
+	role, err := s.RoleRepo.GetRoleByName(utils.RoleAdmin)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	hashedPassword, err := config.Passwords.Hash(password)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	now := time.Now()
+	user := models.User{
+		Username:        username,
+		Email:           email,
+		Password:        hashedPassword,
+		RoleID:          &role.ID,
+		Role:            role,
+		EmailVerified:   true,
+		EmailVerifiedAt: &now,
+	}
+	if err := s.Repo.CreateUser(&user); err != nil {
+		return models.User{}, uniqueViolation(err)
+	}
+	return user, nil
+}
+
+// ResetAdmin sets a new password for an existing account found by username or email,
+// gives it the admin role, reactivates it and revokes all of its tokens.
+func (s *UserService) ResetAdmin(identifier, password string) (models.User, error) {
+	var user models.User
+	var err error
+	if strings.Contains(identifier, "@") {
+		user, err = s.Repo.GetUserByEmail(identifier)
+	} else {
+		user, err = s.Repo.GetUserByUsername(identifier)
+	}
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.User{}, utils.ErrNotFound
+		}
+		return models.User{}, err
+	}
+
+	if err := config.PasswordPolicy.Validate(password, user.Username, user.Email); err != nil {
+		return models.User{}, err
+	}
+
+	role, err := s.RoleRepo.GetRoleByName(utils.RoleAdmin)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	hashedPassword, err := config.Passwords.Hash(password)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user.Password = hashedPassword
+	user.RoleID = &role.ID
+	user.Role = role
+	user.IsActive = true
+	user.DeactivatedAt = nil
+	if !user.EmailVerified {
+		now := time.Now()
+		user.EmailVerified = true
+		user.EmailVerifiedAt = &now
+	}
+	if err := s.Repo.UpdateUser(&user); err != nil {
+		return models.User{}, err
+	}
+
+	if err := cache.SetUserDisabled(user.ID, false); err != nil {
+		return models.User{}, err
+	}
+	return user, s.AuthService.LogoutAll(user.ID)
+}
+
+// validateAdminCredentials checks the input of CreateAdmin
+func validateAdminCredentials(username, email, password string) error {
+	if strings.TrimSpace(username) == "" {
+		return utils.ErrBadRequest
+	}
+	if _, err := mail.ParseAddress(email); err != nil {
+		return utils.ErrBadRequest
+	}
+	return config.PasswordPolicy.Validate(password, username, email)
+}
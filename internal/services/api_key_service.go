@@ -0,0 +1,177 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"mentalartsapi/internal/dto"
+	"mentalartsapi/internal/models"
+	"mentalartsapi/internal/repository"
+	"mentalartsapi/internal/utils"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"gorm.io/gorm"
+)
+
+const (
+	// apiKeyPrefix makes keys easy to recognise, e.g. in secret scanners
+	apiKeyPrefix = "mak_"
+	// apiKeyTouchInterval limits how often last_used_at is written for busy keys
+	apiKeyTouchInterval = time.Minute
+)
+
+// APIKeyService manages API keys and authenticates requests made with them
+type APIKeyService struct {
+	Repo     repository.APIKeyRepository
+	UserRepo repository.UserRepository
+}
+
+// NewAPIKeyService creates a new APIKeyService
+func NewAPIKeyService(repo repository.APIKeyRepository, userRepo repository.UserRepository) *APIKeyService {
+	return &APIKeyService{Repo: repo, UserRepo: userRepo}
+}
+
+// CreateKey creates a key for the user. Scopes must be a subset of the user's permissions.
+// The returned secret is shown once and cannot be recovered later.
+func (s *APIKeyService) CreateKey(userID uint, req dto.CreateAPIKeyRequestDTO) (dto.CreatedAPIKeyResponseDTO, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return dto.CreatedAPIKeyResponseDTO{}, utils.ErrBadRequest
+	}
+
+	user, err := s.UserRepo.GetUserByID(userID)
+	if err != nil {
+		return dto.CreatedAPIKeyResponseDTO{}, err
+	}
+
+	granted := user.Role.PermissionNames()
+	for _, scope := range req.Scopes {
+		// API keys are refused on the self-service routes, so this scope would grant nothing
+		if scope == utils.PermAccountManage || !containsString(granted, scope) {
+			return dto.CreatedAPIKeyResponseDTO{}, utils.ErrScopeNotAllowed
+		}
+	}
+
+	secret, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return dto.CreatedAPIKeyResponseDTO{}, err
+	}
+	rawKey := apiKeyPrefix + secret
+
+	key := models.APIKey{
+		UserID:    userID,
+		Name:      req.Name,
+		Prefix:    rawKey[:len(apiKeyPrefix)+8],
+		KeyHash:   utils.HashToken(rawKey),
+		Scopes:    uniqueStrings(req.Scopes),
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := s.Repo.CreateKey(&key); err != nil {
+		return dto.CreatedAPIKeyResponseDTO{}, err
+	}
+
+	return dto.CreatedAPIKeyResponseDTO{APIKeyResponseDTO: toAPIKeyResponseDTO(key), Key: rawKey}, nil
+}
+
+// ListKeys returns every key of the user, including revoked and expired ones
+func (s *APIKeyService) ListKeys(userID uint) ([]dto.APIKeyResponseDTO, error) {
+	keys, err := s.Repo.GetKeysForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	keyDTOs := make([]dto.APIKeyResponseDTO, 0, len(keys))
+	for _, key := range keys {
+		keyDTOs = append(keyDTOs, toAPIKeyResponseDTO(key))
+	}
+	return keyDTOs, nil
+}
+
+// RevokeKey revokes one of the user's keys
+func (s *APIKeyService) RevokeKey(userID, keyID uint) error {
+	revoked, err := s.Repo.RevokeKey(keyID, userID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return utils.ErrNotFound
+	}
+	return nil
+}
+
+// Authenticate resolves an API key to the claims of its owner.
+// The resulting permissions are the key's scopes limited to what the owner's role still grants.
+func (s *APIKeyService) Authenticate(rawKey string) (*utils.JWTClaims, error) {
+	key, err := s.Repo.GetKeyByHash(utils.HashToken(rawKey))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, utils.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && now.After(*key.ExpiresAt)) {
+		return nil, utils.ErrInvalidAPIKey
+	}
+	if !key.User.IsActive {
+		return nil, utils.ErrAccountDisabled
+	}
+
+	granted := key.User.Role.PermissionNames()
+	permissions := make([]string, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		if containsString(granted, scope) {
+			permissions = append(permissions, scope)
+		}
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyTouchInterval {
+		if err := s.Repo.TouchKey(key.ID, now); err != nil {
+			log.Println("Error updating API key last use:", err)
+		}
+	}
+
+	return &utils.JWTClaims{
+		ID:          key.User.ID,
+		Email:       key.User.Email,
+		Role:        key.User.Role.Name,
+		Permissions: permissions,
+		StandardClaims: jwt.StandardClaims{
+			Id:       fmt.Sprintf("apikey:%d", key.ID),
+			IssuedAt: now.Unix(),
+		},
+	}, nil
+}
+
+// toAPIKeyResponseDTO maps an API key to its public representation
+func toAPIKeyResponseDTO(key models.APIKey) dto.APIKeyResponseDTO {
+	return dto.APIKeyResponseDTO{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueStrings(values []string) []string {
+	unique := make([]string, 0, len(values))
+	for _, v := range values {
+		if !containsString(unique, v) {
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
@@ -1,25 +1,57 @@
 package services
 
 import (
+	"log"
+	"mentalartsapi/config"
+	"mentalartsapi/internal/cache"
 	"mentalartsapi/internal/dto"
+	"mentalartsapi/internal/mailer"
 	"mentalartsapi/internal/models"
 	"mentalartsapi/internal/repository"
+	"mentalartsapi/internal/utils"
+	"strconv"
+	"time"
 
-	"golang.org/x/crypto/bcrypt"
+	"github.com/dgrijalva/jwt-go"
+	"gorm.io/gorm"
 )
 
 type AuthService struct {
-	Repo repository.UserRepository
+	Repo        repository.UserRepository
+	RoleRepo    repository.RoleRepository
+	TokenRepo   repository.RefreshTokenRepository
+	ResetRepo   repository.PasswordResetRepository
+	SessionRepo repository.SessionRepository
+	KeyRepo     repository.APIKeyRepository
+	Mailer      mailer.Mailer
 }
 
-func NewAuthService(repo repository.UserRepository) *AuthService {
-	return &AuthService{Repo: repo}
+func NewAuthService(repo repository.UserRepository, roleRepo repository.RoleRepository, tokenRepo repository.RefreshTokenRepository, resetRepo repository.PasswordResetRepository, sessionRepo repository.SessionRepository, keyRepo repository.APIKeyRepository, mailer mailer.Mailer) *AuthService {
+	return &AuthService{Repo: repo, RoleRepo: roleRepo, TokenRepo: tokenRepo, ResetRepo: resetRepo, SessionRepo: sessionRepo, KeyRepo: keyRepo, Mailer: mailer}
 }
 
-// RegisterUser registers a new user
+// RegisterUser registers a new user. A username or email address already in use,
+// ignoring case, fails with utils.ErrUsernameTaken or utils.ErrEmailTaken.
 func (s *AuthService) RegisterUser(dto dto.RegisterRequestDTO) (models.User, error) {
+	if err := config.PasswordPolicy.Validate(dto.Password, dto.Username, dto.Email); err != nil {
+		return models.User{}, err
+	}
+
+	if err := ensureUnique(s.Repo.UsernameTaken, dto.Username, 0, utils.ErrUsernameTaken); err != nil {
+		return models.User{}, err
+	}
+	if err := ensureUnique(s.Repo.EmailTaken, dto.Email, 0, utils.ErrEmailTaken); err != nil {
+		return models.User{}, err
+	}
+
 	// Hash password
-	hashPassword, err := bcrypt.GenerateFromPassword([]byte(dto.Password), bcrypt.DefaultCost)
+	hashPassword, err := config.Passwords.Hash(dto.Password)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	// New accounts start with the default role
+	role, err := s.RoleRepo.GetRoleByName(utils.RoleUser)
 	if err != nil {
 		return models.User{}, err
 	}
@@ -28,34 +60,279 @@ func (s *AuthService) RegisterUser(dto dto.RegisterRequestDTO) (models.User, err
 	user := models.User{
 		Username: dto.Username,
 		Email:    dto.Email,
-		Password: string(hashPassword),
+		Password: hashPassword,
+		RoleID:   &role.ID,
+		Role:     role,
 	}
 
 	err = s.Repo.CreateUser(&user)
 	if err != nil {
-		return models.User{}, err
+		return models.User{}, uniqueViolation(err)
 	}
 
 	return user, nil
 }
 
-// LoginUser checks if user credentials are valid
+// LoginUser checks if user credentials are valid.
+// Passwords stored with an outdated algorithm or parameters are rehashed on success.
 func (s *AuthService) LoginUser(dto dto.LoginRequestDTO) (models.User, error) {
 	user, err := s.Repo.GetUserByEmail(dto.Email)
 	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.User{}, utils.ErrInvalidCredentials
+		}
 		return models.User{}, err
 	}
 
+	// Accounts created through the identity provider have no password
+	if user.Password == "" {
+		return models.User{}, utils.ErrInvalidCredentials
+	}
+
 	// Compare password
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(dto.Password))
+	ok, needsRehash, err := config.Passwords.Verify(user.Password, dto.Password)
 	if err != nil {
 		return models.User{}, err
 	}
+	if !ok {
+		return models.User{}, utils.ErrInvalidCredentials
+	}
+
+	if needsRehash {
+		s.rehashPassword(&user, dto.Password)
+	}
+
+	if !user.IsActive {
+		return models.User{}, utils.ErrAccountDisabled
+	}
+
+	if config.Auth.RequireEmailVerification && !user.EmailVerified {
+		return models.User{}, utils.ErrEmailNotVerified
+	}
 
 	return user, nil
 }
 
+// rehashPassword replaces the stored hash with one made with the current settings.
+// Failing to do so is not fatal; the old hash keeps working.
+func (s *AuthService) rehashPassword(user *models.User, password string) {
+	hashed, err := config.Passwords.Hash(password)
+	if err != nil {
+		log.Println("Error rehashing password:", err)
+		return
+	}
+
+	// Only the hash is written, so a concurrent profile or role change is not overwritten
+	// with the values loaded for this login
+	if err := s.Repo.UpdatePassword(user.ID, hashed); err != nil {
+		log.Println("Error storing rehashed password:", err)
+		return
+	}
+	user.Password = hashed
+}
+
 // GetUserByID fetches a user by ID
 func (s *AuthService) GetUserByID(userID uint) (models.User, error) {
 	return s.Repo.GetUserByID(userID)
 }
+
+// IssueTokens starts a new session with its own refresh token family and returns a fresh token pair
+func (s *AuthService) IssueTokens(user models.User, client ClientInfo) (dto.TokenResponseDTO, error) {
+	familyID, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return dto.TokenResponseDTO{}, err
+	}
+
+	rawToken, refreshToken, err := s.newRefreshToken(user.ID, familyID)
+	if err != nil {
+		return dto.TokenResponseDTO{}, err
+	}
+
+	if err := s.TokenRepo.CreateToken(&refreshToken); err != nil {
+		return dto.TokenResponseDTO{}, err
+	}
+
+	session, err := s.startSession(user.ID, familyID, client, refreshToken.ExpiresAt)
+	if err != nil {
+		return dto.TokenResponseDTO{}, err
+	}
+
+	return s.tokenResponse(user, session.ID, rawToken)
+}
+
+// RefreshTokens rotates a refresh token and returns a new token pair.
+// Presenting a token that has already been rotated or revoked is treated as
+// theft: the whole token family is revoked and the request is rejected.
+// Tokens of a family whose session was deleted are rejected the same way.
+func (s *AuthService) RefreshTokens(rawToken string, client ClientInfo) (dto.TokenResponseDTO, error) {
+	current, err := s.TokenRepo.GetTokenByHash(utils.HashToken(rawToken))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return dto.TokenResponseDTO{}, utils.ErrInvalidRefreshToken
+		}
+		return dto.TokenResponseDTO{}, err
+	}
+
+	if current.RevokedAt != nil {
+		if err := s.revokeFamily(current.FamilyID); err != nil {
+			return dto.TokenResponseDTO{}, err
+		}
+		return dto.TokenResponseDTO{}, utils.ErrInvalidRefreshToken
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		return dto.TokenResponseDTO{}, utils.ErrInvalidRefreshToken
+	}
+
+	user, err := s.Repo.GetUserByID(current.UserID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return dto.TokenResponseDTO{}, utils.ErrInvalidRefreshToken
+		}
+		return dto.TokenResponseDTO{}, err
+	}
+	if !user.IsActive {
+		return dto.TokenResponseDTO{}, utils.ErrInvalidRefreshToken
+	}
+
+	// A family without a session was signed out, e.g. through DELETE /me/sessions/:id
+	session, err := s.SessionRepo.GetSessionByFamily(current.FamilyID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			if err := s.revokeFamily(current.FamilyID); err != nil {
+				return dto.TokenResponseDTO{}, err
+			}
+			return dto.TokenResponseDTO{}, utils.ErrInvalidRefreshToken
+		}
+		return dto.TokenResponseDTO{}, err
+	}
+
+	newRawToken, replacement, err := s.newRefreshToken(user.ID, current.FamilyID)
+	if err != nil {
+		return dto.TokenResponseDTO{}, err
+	}
+
+	rotated, err := s.TokenRepo.RotateToken(&current, &replacement)
+	if err != nil {
+		return dto.TokenResponseDTO{}, err
+	}
+	if !rotated {
+		// Another request rotated this token first, so it is being reused
+		if err := s.revokeFamily(current.FamilyID); err != nil {
+			return dto.TokenResponseDTO{}, err
+		}
+		return dto.TokenResponseDTO{}, utils.ErrInvalidRefreshToken
+	}
+
+	if err := s.SessionRepo.TouchSession(session.ID, client.IPAddress, client.UserAgent, replacement.ExpiresAt); err != nil {
+		return dto.TokenResponseDTO{}, err
+	}
+
+	return s.tokenResponse(user, session.ID, newRawToken)
+}
+
+// Logout revokes the token family the given refresh token belongs to
+func (s *AuthService) Logout(rawToken string) error {
+	token, err := s.TokenRepo.GetTokenByHash(utils.HashToken(rawToken))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return utils.ErrInvalidRefreshToken
+		}
+		return err
+	}
+
+	return s.revokeFamily(token.FamilyID)
+}
+
+// LogoutAll revokes every refresh token the user holds and every access token issued so far
+func (s *AuthService) LogoutAll(userID uint) error {
+	if err := s.TokenRepo.RevokeAllForUser(userID); err != nil {
+		return err
+	}
+	return cache.SetTokensValidAfter(userID, time.Now())
+}
+
+// RevokeAccessToken puts a single access token on the denylist until it expires
+func (s *AuthService) RevokeAccessToken(tokenString string) error {
+	claims := &utils.JWTClaims{}
+	if err := config.JWT.Parse(tokenString, claims); err != nil {
+		return err
+	}
+	return cache.DenyToken(claims.Id, time.Unix(claims.ExpiresAt, 0))
+}
+
+// RevokeUserTokens force-expires every access and refresh token of a user and revokes
+// the user's API keys, which are not covered by the access token checks
+func (s *AuthService) RevokeUserTokens(userID uint) error {
+	if _, err := s.Repo.GetUserByID(userID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return utils.ErrNotFound
+		}
+		return err
+	}
+	if err := s.KeyRepo.RevokeAllForUser(userID); err != nil {
+		return err
+	}
+	return s.LogoutAll(userID)
+}
+
+// newRefreshToken generates a raw refresh token and the record that stores its hash
+func (s *AuthService) newRefreshToken(userID uint, familyID string) (string, models.RefreshToken, error) {
+	rawToken, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", models.RefreshToken{}, err
+	}
+
+	return rawToken, models.RefreshToken{
+		UserID:    userID,
+		TokenHash: utils.HashToken(rawToken),
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(config.Auth.RefreshTokenTTL),
+	}, nil
+}
+
+// tokenResponse signs an access token for the session and pairs it with the given refresh token
+func (s *AuthService) tokenResponse(user models.User, sessionID uint, refreshToken string) (dto.TokenResponseDTO, error) {
+	accessToken, err := generateAccessToken(user, sessionID)
+	if err != nil {
+		return dto.TokenResponseDTO{}, err
+	}
+
+	return dto.TokenResponseDTO{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(config.Auth.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// generateAccessToken signs a short-lived JWT for the user with the active signing key
+func generateAccessToken(user models.User, sessionID uint) (string, error) {
+	jti, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := &utils.JWTClaims{
+		ID:          user.ID,
+		Email:       user.Email,
+		Role:        user.Role.Name,
+		Permissions: user.Role.PermissionNames(),
+		SessionID:   sessionID,
+		IssuedAtMs:  now.UnixMilli(),
+		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			Subject:   strconv.FormatUint(uint64(user.ID), 10),
+			ExpiresAt: now.Add(config.Auth.AccessTokenTTL).Unix(),
+			IssuedAt:  now.Unix(),
+		},
+	}
+
+	return config.JWT.Sign(claims)
+}
+
+// JWKS returns the public signing keys so other services can verify access tokens
+func (s *AuthService) JWKS() utils.JWKSet {
+	return config.JWT.JWKS()
+}
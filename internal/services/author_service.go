@@ -3,15 +3,19 @@ package services
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"mentalartsapi/internal/cache"
 	"mentalartsapi/internal/dto"
 	"mentalartsapi/internal/models"
 	"mentalartsapi/internal/repository"
+	"mentalartsapi/internal/utils"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 )
 
+// dateLayout is the format of dates without a time, such as birth dates
+const dateLayout = "2006-01-02"
+
 // AuthorService manages author operations
 type AuthorService struct {
 	Repo  repository.AuthorRepository
@@ -27,7 +31,7 @@ func NewAuthorService(repo repository.AuthorRepository, cache *redis.Client, ctx
 // GetAuthors retrieves all authors and converts them to DTO format
 func (s *AuthorService) GetAuthors() ([]dto.AuthorResponseDTO, error) {
 	// Check cache first
-	cacheKey := "authors_list"
+	cacheKey := cache.AuthorListKey
 	cachedData, err := s.Cache.Get(s.Ctx, cacheKey).Result()
 	if err == redis.Nil { // Cache miss
 		// Fetch from DB
@@ -38,12 +42,7 @@ func (s *AuthorService) GetAuthors() ([]dto.AuthorResponseDTO, error) {
 
 		var authorDTOs []dto.AuthorResponseDTO
 		for _, author := range authors {
-			authorDTOs = append(authorDTOs, dto.AuthorResponseDTO{
-				ID:        author.ID,
-				Name:      author.Name,
-				Biography: author.Biography,
-				BirthDate: author.BirthDate,
-			})
+			authorDTOs = append(authorDTOs, toAuthorResponse(author))
 		}
 
 		// Cache the data
@@ -67,7 +66,7 @@ func (s *AuthorService) GetAuthors() ([]dto.AuthorResponseDTO, error) {
 // GetAuthor retrieves a specific author and converts to DTO format
 func (s *AuthorService) GetAuthor(id uint) (dto.AuthorResponseDTO, error) {
 	// Check cache first
-	cacheKey := fmt.Sprintf("author:%d", id)
+	cacheKey := cache.AuthorKey(id)
 	cachedData, err := s.Cache.Get(s.Ctx, cacheKey).Result()
 	if err == redis.Nil { // Cache miss
 		// Fetch from DB
@@ -76,12 +75,7 @@ func (s *AuthorService) GetAuthor(id uint) (dto.AuthorResponseDTO, error) {
 			return dto.AuthorResponseDTO{}, err
 		}
 
-		authorDTO := dto.AuthorResponseDTO{
-			ID:        author.ID,
-			Name:      author.Name,
-			Biography: author.Biography,
-			BirthDate: author.BirthDate,
-		}
+		authorDTO := toAuthorResponse(author)
 
 		// Cache the data
 		cacheData, _ := json.Marshal(authorDTO)
@@ -103,30 +97,35 @@ func (s *AuthorService) GetAuthor(id uint) (dto.AuthorResponseDTO, error) {
 
 // CreateAuthor creates a new author from DTO request
 func (s *AuthorService) CreateAuthor(req dto.CreateAuthorRequestDTO) (dto.AuthorResponseDTO, error) {
+	birthDate, err := parseBirthDate(req.BirthDate)
+	if err != nil {
+		return dto.AuthorResponseDTO{}, err
+	}
+
 	author := models.Author{
 		Name:      req.Name,
 		Biography: req.Biography,
-		BirthDate: req.BirthDate,
+		BirthDate: birthDate,
 	}
 
-	err := s.Repo.CreateAuthor(&author)
+	err = s.Repo.CreateAuthor(&author)
 	if err != nil {
 		return dto.AuthorResponseDTO{}, err
 	}
 
 	// Invalidate cache when creating a new author
-	s.Cache.Del(s.Ctx, "authors_list")
+	s.Cache.Del(s.Ctx, cache.AuthorListKey)
 
-	return dto.AuthorResponseDTO{
-		ID:        author.ID,
-		Name:      author.Name,
-		Biography: author.Biography,
-		BirthDate: author.BirthDate,
-	}, nil
+	return toAuthorResponse(author), nil
 }
 
 // UpdateAuthor updates an existing author
 func (s *AuthorService) UpdateAuthor(id uint, req dto.CreateAuthorRequestDTO) (dto.AuthorResponseDTO, error) {
+	birthDate, err := parseBirthDate(req.BirthDate)
+	if err != nil {
+		return dto.AuthorResponseDTO{}, err
+	}
+
 	author, err := s.Repo.GetAuthorByID(id)
 	if err != nil {
 		return dto.AuthorResponseDTO{}, err
@@ -134,7 +133,7 @@ func (s *AuthorService) UpdateAuthor(id uint, req dto.CreateAuthorRequestDTO) (d
 
 	author.Name = req.Name
 	author.Biography = req.Biography
-	author.BirthDate = req.BirthDate
+	author.BirthDate = birthDate
 
 	err = s.Repo.UpdateAuthor(&author)
 	if err != nil {
@@ -142,15 +141,10 @@ func (s *AuthorService) UpdateAuthor(id uint, req dto.CreateAuthorRequestDTO) (d
 	}
 
 	// Invalidate cache when updating an author
-	s.Cache.Del(s.Ctx, fmt.Sprintf("author:%d", id))
-	s.Cache.Del(s.Ctx, "authors_list")
+	s.Cache.Del(s.Ctx, cache.AuthorKey(id))
+	s.Cache.Del(s.Ctx, cache.AuthorListKey)
 
-	return dto.AuthorResponseDTO{
-		ID:        author.ID,
-		Name:      author.Name,
-		Biography: author.Biography,
-		BirthDate: author.BirthDate,
-	}, nil
+	return toAuthorResponse(author), nil
 }
 
 // DeleteAuthor deletes an author by ID
@@ -161,8 +155,35 @@ func (s *AuthorService) DeleteAuthor(id uint) error {
 	}
 
 	// Invalidate cache when deleting an author
-	s.Cache.Del(s.Ctx, fmt.Sprintf("author:%d", id))
-	s.Cache.Del(s.Ctx, "authors_list")
+	s.Cache.Del(s.Ctx, cache.AuthorKey(id))
+	s.Cache.Del(s.Ctx, cache.AuthorListKey)
 
 	return nil
 }
+
+// parseBirthDate parses a YYYY-MM-DD birth date, which must not lie in the future.
+// An empty value means the birth date is unknown.
+func parseBirthDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	birthDate, err := time.Parse(dateLayout, value)
+	if err != nil || birthDate.After(time.Now()) {
+		return nil, utils.ErrBadRequest
+	}
+	return &birthDate, nil
+}
+
+// toAuthorResponse maps an author to a DTO
+func toAuthorResponse(author models.Author) dto.AuthorResponseDTO {
+	authorDTO := dto.AuthorResponseDTO{
+		ID:        author.ID,
+		Name:      author.Name,
+		Biography: author.Biography,
+	}
+	if author.BirthDate != nil {
+		birthDate := author.BirthDate.Format(dateLayout)
+		authorDTO.BirthDate = &birthDate
+	}
+	return authorDTO
+}
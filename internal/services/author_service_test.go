@@ -0,0 +1,48 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"mentalartsapi/internal/utils"
+)
+
+func TestParseBirthDate(t *testing.T) {
+	tomorrow := time.Now().AddDate(0, 0, 1).Format(dateLayout)
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string // Empty for an unknown birth date
+		wantErr bool
+	}{
+		{"unknown", "", "", false},
+		{"date", "1908-03-27", "1908-03-27", false},
+		{"not a date", "27.03.1908", "", true},
+		{"in the future", tomorrow, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseBirthDate(tt.value)
+			if tt.wantErr {
+				if err != utils.ErrBadRequest {
+					t.Fatalf("err = %v, want %v", err, utils.ErrBadRequest)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.want == "" {
+				if got != nil {
+					t.Errorf("got %v, want nil", got)
+				}
+				return
+			}
+			if got == nil || got.Format(dateLayout) != tt.want {
+				t.Errorf("got %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
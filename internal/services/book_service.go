@@ -2,11 +2,17 @@ package services
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
-	"fmt"
+	"math"
+	"mentalartsapi/internal/cache"
 	"mentalartsapi/internal/dto"
 	"mentalartsapi/internal/models"
 	"mentalartsapi/internal/repository"
+	"mentalartsapi/internal/utils"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -19,58 +25,191 @@ type BookService struct {
 	Ctx   context.Context
 }
 
+// Orders accepted by GetBooks
+const (
+	BookSortTitle     = "title"
+	BookSortYear      = "year"
+	BookSortCreatedAt = "created_at"
+	BookSortRating    = "rating" // Average rating
+)
+
+// bookSortColumns maps the orders accepted by GetBooks to columns
+var bookSortColumns = map[string]string{
+	BookSortTitle:     "title",
+	BookSortYear:      "publication_year",
+	BookSortCreatedAt: "created_at",
+	BookSortRating:    "rating_average",
+}
+
+// bookListCacheTTL is shorter than for single books because every query gets its own key
+const bookListCacheTTL = 10 * time.Minute
+
 // NewBookService creates a new BookService
 func NewBookService(repo repository.BookRepository, cache *redis.Client, ctx context.Context) *BookService {
 	return &BookService{Repo: repo, Cache: cache, Ctx: ctx}
 }
 
-// GetBooks retrieves all books and maps them to DTOs
-func (s *BookService) GetBooks() ([]dto.BookResponseDTO, error) {
-	// Check cache first
-	cacheKey := "books_list"
-	cachedData, err := s.Cache.Get(s.Ctx, cacheKey).Result()
-	if err == redis.Nil { // Cache miss
-		// Fetch from DB
-		books, err := s.Repo.GetAllBooks()
-		if err != nil {
-			return nil, err
+// GetBooks retrieves one page of the books matching the query and maps them to DTOs.
+// A cursor from a previous page continues after its last book; it fails with
+// utils.ErrBadRequest if it is invalid or was made for another order.
+func (s *BookService) GetBooks(query dto.BookQueryDTO) (dto.BookListResponseDTO, error) {
+	query.ISBN = normalizeISBN(query.ISBN)
+	if query.Sort == "" {
+		query.Sort = BookSortCreatedAt
+	}
+	if query.Order == "" {
+		query.Order = "asc"
+		if query.Sort == BookSortCreatedAt || query.Sort == BookSortRating {
+			query.Order = "desc"
 		}
+	}
 
-		var bookDTOs []dto.BookResponseDTO
-		for _, book := range books {
-			bookDTOs = append(bookDTOs, dto.BookResponseDTO{
-				ID:              book.ID,
-				Title:           book.Title,
-				ISBN:            book.ISBN,
-				PublicationYear: book.PublicationYear,
-				Description:     book.Description,
-				AuthorID:        book.AuthorID,
-				AuthorName:      book.Author.Name,
-			})
+	filter := repository.BookFilter{
+		AuthorID:   query.AuthorID,
+		YearFrom:   query.YearFrom,
+		YearTo:     query.YearTo,
+		ISBN:       query.ISBN,
+		Title:      query.Title,
+		MinRating:  query.MinRating,
+		SortColumn: bookSortColumns[query.Sort],
+		Desc:       query.Order == "desc",
+		Offset:     (query.Page - 1) * query.PageSize,
+		Limit:      query.PageSize + 1, // One more to know whether there is a next page
+	}
+	if query.Cursor != "" {
+		after, err := decodeBookCursor(query.Cursor, query.Sort, query.Order)
+		if err != nil {
+			return dto.BookListResponseDTO{}, err
 		}
+		filter.After = after
+		query.Page = 0
+	}
 
-		// Cache the data
-		cacheData, _ := json.Marshal(bookDTOs)
-		s.Cache.Set(s.Ctx, cacheKey, cacheData, 24*time.Hour) // Cache for 24 hours
-
-		return bookDTOs, nil
-	} else if err != nil {
-		return nil, err
-	} else {
+	// Check cache first
+	cacheKey, err := s.bookListCacheKey(query)
+	if err != nil {
+		return dto.BookListResponseDTO{}, err
+	}
+	cachedData, err := s.Cache.Get(s.Ctx, cacheKey).Result()
+	if err == nil {
 		// Cache hit, unmarshal the cached data
-		var bookDTOs []dto.BookResponseDTO
-		err := json.Unmarshal([]byte(cachedData), &bookDTOs)
-		if err != nil {
-			return nil, err
+		var list dto.BookListResponseDTO
+		if err := json.Unmarshal([]byte(cachedData), &list); err != nil {
+			return dto.BookListResponseDTO{}, err
 		}
-		return bookDTOs, nil
+		return list, nil
+	} else if err != redis.Nil {
+		return dto.BookListResponseDTO{}, err
 	}
+
+	// Cache miss, fetch from DB
+	books, total, err := s.Repo.ListBooks(filter)
+	if err != nil {
+		return dto.BookListResponseDTO{}, err
+	}
+
+	list := dto.BookListResponseDTO{
+		Books:    make([]dto.BookResponseDTO, 0, len(books)),
+		Page:     query.Page,
+		PageSize: query.PageSize,
+		Total:    total,
+	}
+	if len(books) > query.PageSize {
+		books = books[:query.PageSize]
+		list.NextCursor = encodeBookCursor(books[len(books)-1], query.Sort, query.Order)
+	}
+	for _, book := range books {
+		list.Books = append(list.Books, toBookResponse(book))
+	}
+
+	// Cache the data
+	cacheData, _ := json.Marshal(list)
+	s.Cache.Set(s.Ctx, cacheKey, cacheData, bookListCacheTTL)
+
+	return list, nil
+}
+
+// bookListCacheKey builds the cache key of a normalised book query under the current list version
+func (s *BookService) bookListCacheKey(query dto.BookQueryDTO) (string, error) {
+	version, err := s.Cache.Get(s.Ctx, cache.BookListVersionKey).Int64()
+	if err != nil && err != redis.Nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("author_id", strconv.FormatUint(uint64(query.AuthorID), 10))
+	params.Set("year_from", strconv.Itoa(query.YearFrom))
+	params.Set("year_to", strconv.Itoa(query.YearTo))
+	params.Set("isbn", query.ISBN)
+	params.Set("title", strings.ToLower(query.Title))
+	params.Set("min_rating", strconv.FormatFloat(query.MinRating, 'g', -1, 64))
+	params.Set("sort", query.Sort)
+	params.Set("order", query.Order)
+	params.Set("cursor", query.Cursor)
+	params.Set("page", strconv.Itoa(query.Page))
+	params.Set("page_size", strconv.Itoa(query.PageSize))
+
+	return cache.BookListKey(version, params.Encode()), nil
+}
+
+// bookCursor is the position of the last book on a page, tied to the order it was listed in
+type bookCursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+// encodeBookCursor creates an opaque cursor pointing after the given book
+func encodeBookCursor(book models.Book, sort, order string) string {
+	cursor := bookCursor{Sort: sort, Order: order, ID: book.ID}
+	switch sort {
+	case BookSortTitle:
+		cursor.Value = book.Title
+	case BookSortYear:
+		cursor.Value = strconv.Itoa(book.PublicationYear)
+	case BookSortRating:
+		cursor.Value = strconv.FormatFloat(book.RatingAverage, 'g', -1, 64)
+	default:
+		cursor.Value = book.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeBookCursor reads a cursor made by encodeBookCursor for the same order
+func decodeBookCursor(encoded, sort, order string) (*repository.BookKey, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, utils.ErrBadRequest
+	}
+	var cursor bookCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Sort != sort || cursor.Order != order {
+		return nil, utils.ErrBadRequest
+	}
+
+	key := &repository.BookKey{ID: cursor.ID}
+	switch sort {
+	case BookSortTitle:
+		key.Value = cursor.Value
+	case BookSortYear:
+		key.Value, err = strconv.Atoi(cursor.Value)
+	case BookSortRating:
+		key.Value, err = strconv.ParseFloat(cursor.Value, 64)
+	default:
+		key.Value, err = time.Parse(time.RFC3339Nano, cursor.Value)
+	}
+	if err != nil {
+		return nil, utils.ErrBadRequest
+	}
+	return key, nil
 }
 
 // GetBook retrieves a specific book and maps it to a DTO
 func (s *BookService) GetBook(id uint) (dto.BookResponseDTO, error) {
 	// Check cache first
-	cacheKey := fmt.Sprintf("book:%d", id)
+	cacheKey := cache.BookKey(id)
 	cachedData, err := s.Cache.Get(s.Ctx, cacheKey).Result()
 	if err == redis.Nil { // Cache miss
 		// Fetch from DB
@@ -79,15 +218,7 @@ func (s *BookService) GetBook(id uint) (dto.BookResponseDTO, error) {
 			return dto.BookResponseDTO{}, err
 		}
 
-		bookDTO := dto.BookResponseDTO{
-			ID:              book.ID,
-			Title:           book.Title,
-			ISBN:            book.ISBN,
-			PublicationYear: book.PublicationYear,
-			Description:     book.Description,
-			AuthorID:        book.AuthorID,
-			AuthorName:      book.Author.Name,
-		}
+		bookDTO := toBookResponse(book)
 
 		// Cache the data
 		cacheData, _ := json.Marshal(bookDTO)
@@ -107,12 +238,17 @@ func (s *BookService) GetBook(id uint) (dto.BookResponseDTO, error) {
 	}
 }
 
+// normalizeISBN removes the hyphens and spaces of an ISBN so it is stored and searched as digits only
+func normalizeISBN(isbn string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(isbn)
+}
+
 // CreateBook creates a new book from a DTO
 func (s *BookService) CreateBook(req dto.CreateBookRequestDTO) (dto.BookResponseDTO, error) {
 	book := models.Book{
 		Title:           req.Title,
 		AuthorID:        req.AuthorID,
-		ISBN:            req.ISBN,
+		ISBN:            normalizeISBN(req.ISBN),
 		PublicationYear: req.PublicationYear,
 		Description:     req.Description,
 	}
@@ -129,17 +265,9 @@ func (s *BookService) CreateBook(req dto.CreateBookRequestDTO) (dto.BookResponse
 	}
 
 	// Invalidate cache when creating a new book
-	s.Cache.Del(s.Ctx, "books_list")
+	s.Cache.Incr(s.Ctx, cache.BookListVersionKey)
 
-	return dto.BookResponseDTO{
-		ID:              createdBook.ID,
-		Title:           createdBook.Title,
-		ISBN:            createdBook.ISBN,
-		PublicationYear: createdBook.PublicationYear,
-		Description:     createdBook.Description,
-		AuthorID:        createdBook.AuthorID,
-		AuthorName:      createdBook.Author.Name,
-	}, nil
+	return toBookResponse(createdBook), nil
 }
 
 // UpdateBook updates an existing book using a DTO
@@ -151,7 +279,7 @@ func (s *BookService) UpdateBook(id uint, req dto.CreateBookRequestDTO) (dto.Boo
 
 	book.Title = req.Title
 	book.AuthorID = req.AuthorID
-	book.ISBN = req.ISBN
+	book.ISBN = normalizeISBN(req.ISBN)
 	book.PublicationYear = req.PublicationYear
 	book.Description = req.Description
 
@@ -167,18 +295,10 @@ func (s *BookService) UpdateBook(id uint, req dto.CreateBookRequestDTO) (dto.Boo
 	}
 
 	// Invalidate cache when updating a book
-	s.Cache.Del(s.Ctx, fmt.Sprintf("book:%d", id))
-	s.Cache.Del(s.Ctx, "books_list")
+	s.Cache.Del(s.Ctx, cache.BookKey(id))
+	s.Cache.Incr(s.Ctx, cache.BookListVersionKey)
 
-	return dto.BookResponseDTO{
-		ID:              updatedBook.ID,
-		Title:           updatedBook.Title,
-		ISBN:            updatedBook.ISBN,
-		PublicationYear: updatedBook.PublicationYear,
-		Description:     updatedBook.Description,
-		AuthorID:        updatedBook.AuthorID,
-		AuthorName:      updatedBook.Author.Name,
-	}, nil
+	return toBookResponse(updatedBook), nil
 }
 
 // DeleteBook deletes a book by ID
@@ -189,8 +309,37 @@ func (s *BookService) DeleteBook(id uint) error {
 	}
 
 	// Invalidate cache when deleting a book
-	s.Cache.Del(s.Ctx, fmt.Sprintf("book:%d", id))
-	s.Cache.Del(s.Ctx, "books_list")
+	s.Cache.Del(s.Ctx, cache.BookKey(id))
+	s.Cache.Incr(s.Ctx, cache.BookListVersionKey)
 
 	return nil
 }
+
+// toBookResponse maps a book with its author preloaded to a DTO
+func toBookResponse(book models.Book) dto.BookResponseDTO {
+	average := 0.0
+	if book.RatingCount > 0 {
+		average = math.Round(book.RatingAverage*100) / 100
+	}
+
+	return dto.BookResponseDTO{
+		ID:              book.ID,
+		Title:           book.Title,
+		ISBN:            book.ISBN,
+		PublicationYear: book.PublicationYear,
+		Description:     book.Description,
+		AuthorID:        book.AuthorID,
+		AuthorName:      book.Author.Name,
+		Rating: dto.RatingSummaryDTO{
+			Count:   book.RatingCount,
+			Average: average,
+			Histogram: map[string]int{
+				"1": book.Rating1Count,
+				"2": book.Rating2Count,
+				"3": book.Rating3Count,
+				"4": book.Rating4Count,
+				"5": book.Rating5Count,
+			},
+		},
+	}
+}
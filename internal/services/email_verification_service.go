@@ -0,0 +1,118 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"mentalartsapi/config"
+	"mentalartsapi/internal/cache"
+	"mentalartsapi/internal/mailer"
+	"mentalartsapi/internal/models"
+	"mentalartsapi/internal/utils"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"gorm.io/gorm"
+)
+
+// emailVerificationAudience is the audience of email verification tokens
+const emailVerificationAudience = "verify-email"
+
+// SendVerificationEmail mails the user a signed, single-use verification link
+func (s *AuthService) SendVerificationEmail(user models.User) error {
+	jti, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return err
+	}
+
+	token, err := config.JWT.SignClaims(&utils.ActionTokenClaims{
+		UserID: user.ID,
+		Email:  user.Email,
+		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			Subject:   strconv.FormatUint(uint64(user.ID), 10),
+			Audience:  emailVerificationAudience,
+			Issuer:    config.JWT.Issuer,
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: time.Now().Add(config.Auth.EmailVerificationTTL).Unix(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/api/v1/auth/verify-email?token=%s", config.Auth.AppBaseURL, url.QueryEscape(token))
+	return s.Mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hello %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\nThe link expires in %s.\n",
+			user.Username, link, config.Auth.EmailVerificationTTL),
+	})
+}
+
+// VerifyEmail consumes a verification token and marks the user's email as verified.
+// The token is bound to the address it was sent to, so it stops working if the email changes.
+func (s *AuthService) VerifyEmail(token string) error {
+	claims := &utils.ActionTokenClaims{}
+	if err := config.JWT.ParseClaims(token, claims); err != nil {
+		return utils.ErrInvalidActionToken
+	}
+	if !claims.VerifyAudience(emailVerificationAudience, true) || !claims.VerifyIssuer(config.JWT.Issuer, true) || claims.Id == "" {
+		return utils.ErrInvalidActionToken
+	}
+
+	user, err := s.Repo.GetUserByID(claims.UserID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return utils.ErrInvalidActionToken
+		}
+		return err
+	}
+	if user.Email != claims.Email {
+		return utils.ErrInvalidActionToken
+	}
+
+	firstUse, err := cache.MarkTokenUsed(claims.Id, time.Unix(claims.ExpiresAt, 0))
+	if err != nil {
+		return err
+	}
+	if !firstUse {
+		return utils.ErrInvalidActionToken
+	}
+
+	if user.EmailVerified {
+		return nil
+	}
+
+	now := time.Now()
+	user.EmailVerified = true
+	user.EmailVerifiedAt = &now
+	return s.Repo.UpdateUser(&user)
+}
+
+// ResendVerification sends a new verification email if the address belongs to an unverified user.
+// Unknown and already verified addresses are ignored so the endpoint cannot be used to probe accounts.
+func (s *AuthService) ResendVerification(email string) error {
+	user, err := s.Repo.GetUserByEmail(email)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return err
+	}
+	if user.EmailVerified {
+		return nil
+	}
+
+	allowed, err := cache.AcquireCooldown(fmt.Sprintf("verify-email:%d", user.ID), time.Minute)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		log.Printf("Verification email for user %d skipped, cooldown active", user.ID)
+		return nil
+	}
+
+	return s.SendVerificationEmail(user)
+}
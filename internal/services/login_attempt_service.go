@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"mentalartsapi/config"
+	"mentalartsapi/internal/utils"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// LoginAttemptService tracks failed logins per email and per client IP in Redis.
+// Repeated failures first slow the client down with an exponential backoff and
+// then lock the account (per email) or block the client (per IP) for a while.
+type LoginAttemptService struct {
+	Cache *redis.Client // Redis client
+	Ctx   context.Context
+}
+
+// NewLoginAttemptService creates a new LoginAttemptService
+func NewLoginAttemptService(cache *redis.Client, ctx context.Context) *LoginAttemptService {
+	return &LoginAttemptService{Cache: cache, Ctx: ctx}
+}
+
+// Check returns a *utils.RetryAfterError if the email or IP may not try to log in right now
+func (s *LoginAttemptService) Check(email, ip string) error {
+	email = normalizeEmail(email)
+
+	if ttl, err := s.activeTTL(loginKey("lock", "email", email)); err != nil {
+		return err
+	} else if ttl > 0 {
+		return &utils.RetryAfterError{Err: utils.ErrAccountLocked, RetryAfter: ttl}
+	}
+
+	for _, key := range []string{
+		loginKey("lock", "ip", ip),
+		loginKey("backoff", "email", email),
+		loginKey("backoff", "ip", ip),
+	} {
+		if ttl, err := s.activeTTL(key); err != nil {
+			return err
+		} else if ttl > 0 {
+			return &utils.RetryAfterError{Err: utils.ErrTooManyAttempts, RetryAfter: ttl}
+		}
+	}
+	return nil
+}
+
+// RecordFailure counts a failed attempt and starts a backoff or lockout when a threshold is reached
+func (s *LoginAttemptService) RecordFailure(email, ip string) error {
+	email = normalizeEmail(email)
+	cfg := config.Auth
+
+	if err := s.registerFailure("email", email, cfg.LoginBackoffAfter, cfg.LoginLockoutThreshold); err != nil {
+		return err
+	}
+	// Clients behind one IP share the counter, so the IP only backs off once it
+	// clearly exceeds what a single user would produce
+	return s.registerFailure("ip", ip, cfg.LoginIPMaxFailures/2, cfg.LoginIPMaxFailures)
+}
+
+// RecordSuccess clears the failure history of an email after a successful login.
+// The IP counter is kept so one valid account cannot be used to reset it.
+func (s *LoginAttemptService) RecordSuccess(email string) error {
+	return s.Unlock(email)
+}
+
+// Unlock removes the lockout, backoff and failure counter of an email
+func (s *LoginAttemptService) Unlock(email string) error {
+	email = normalizeEmail(email)
+	return s.Cache.Del(s.Ctx,
+		loginKey("failures", "email", email),
+		loginKey("backoff", "email", email),
+		loginKey("lock", "email", email),
+	).Err()
+}
+
+// registerFailure increments the failure counter of one subject and applies the resulting penalty
+func (s *LoginAttemptService) registerFailure(kind, subject string, backoffAfter, lockoutThreshold int) error {
+	cfg := config.Auth
+	counterKey := loginKey("failures", kind, subject)
+
+	failures, err := s.Cache.Incr(s.Ctx, counterKey).Result()
+	if err != nil {
+		return err
+	}
+	if failures == 1 {
+		s.Cache.Expire(s.Ctx, counterKey, cfg.LoginFailureWindow)
+	}
+
+	if lockoutThreshold > 0 && failures >= int64(lockoutThreshold) {
+		s.Cache.Del(s.Ctx, counterKey)
+		return s.Cache.Set(s.Ctx, loginKey("lock", kind, subject), "1", cfg.LoginLockoutDuration).Err()
+	}
+
+	if backoffAfter > 0 && failures >= int64(backoffAfter) {
+		delay := backoffDelay(int(failures)-backoffAfter, cfg.LoginBackoffBase, cfg.LoginBackoffMax)
+		return s.Cache.Set(s.Ctx, loginKey("backoff", kind, subject), "1", delay).Err()
+	}
+	return nil
+}
+
+// activeTTL returns the remaining lifetime of a key, or 0 when it does not exist
+func (s *LoginAttemptService) activeTTL(key string) (time.Duration, error) {
+	ttl, err := s.Cache.PTTL(s.Ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// backoffDelay doubles the base delay for every step and caps it at max
+func backoffDelay(step int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 0; i < step && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+func loginKey(name, kind, subject string) string {
+	return fmt.Sprintf("login:%s:%s:%s", name, kind, subject)
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"mentalartsapi/config"
+	"mentalartsapi/internal/testutil"
+	"mentalartsapi/internal/utils"
+)
+
+// useLoginLimits sets small login thresholds for the duration of the test
+func useLoginLimits(t *testing.T) {
+	previous := config.Auth
+	config.Auth.LoginFailureWindow = time.Minute
+	config.Auth.LoginBackoffAfter = 2
+	config.Auth.LoginBackoffBase = time.Second
+	config.Auth.LoginBackoffMax = 4 * time.Second
+	config.Auth.LoginLockoutThreshold = 4
+	config.Auth.LoginLockoutDuration = 10 * time.Minute
+	config.Auth.LoginIPMaxFailures = 100
+	t.Cleanup(func() { config.Auth = previous })
+}
+
+func TestLoginAttemptsBackOffThenLock(t *testing.T) {
+	useLoginLimits(t)
+	server := testutil.UseFakeRedis(t)
+	attempts := NewLoginAttemptService(config.Redis, context.Background())
+
+	fail := func() {
+		t.Helper()
+		if err := attempts.RecordFailure("Jane@Example.com", "203.0.113.9"); err != nil {
+			t.Fatalf("RecordFailure: %v", err)
+		}
+	}
+
+	fail()
+	if err := attempts.Check("jane@example.com", "203.0.113.9"); err != nil {
+		t.Fatalf("Check after one failure = %v, want nil", err)
+	}
+
+	fail()
+	var retryErr *utils.RetryAfterError
+	err := attempts.Check("jane@example.com", "198.51.100.1")
+	if !errors.As(err, &retryErr) || retryErr.Err != utils.ErrTooManyAttempts {
+		t.Fatalf("Check after the backoff threshold = %v, want ErrTooManyAttempts", err)
+	}
+	if retryErr.RetryAfter <= 0 || retryErr.RetryAfter > time.Second {
+		t.Errorf("RetryAfter = %v, want the base delay", retryErr.RetryAfter)
+	}
+
+	fail()
+	if backoff := redisTTL(t, attempts, "login:backoff:email:jane@example.com"); backoff <= time.Second || backoff > 2*time.Second {
+		t.Errorf("second backoff = %v, want it doubled", backoff)
+	}
+
+	fail()
+	err = attempts.Check(" JANE@example.com ", "198.51.100.1")
+	if !errors.As(err, &retryErr) || retryErr.Err != utils.ErrAccountLocked {
+		t.Fatalf("Check at the lockout threshold = %v, want ErrAccountLocked", err)
+	}
+	if retryErr.RetryAfter <= 9*time.Minute {
+		t.Errorf("RetryAfter = %v, want about the lockout duration", retryErr.RetryAfter)
+	}
+	if _, ok := server.Value("login:failures:email:jane@example.com"); ok {
+		t.Error("the failure counter was kept after the lockout")
+	}
+
+	if err := attempts.Unlock("jane@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := attempts.Check("jane@example.com", "198.51.100.1"); err != nil {
+		t.Errorf("Check after Unlock = %v, want nil", err)
+	}
+	// The IP counter survives the unlock
+	if count, _ := server.Value("login:failures:ip:203.0.113.9"); count != "4" {
+		t.Errorf("IP failure counter = %q, want 4", count)
+	}
+}
+
+func TestLoginAttemptsBlockIP(t *testing.T) {
+	useLoginLimits(t)
+	config.Auth.LoginIPMaxFailures = 3
+	testutil.UseFakeRedis(t)
+	attempts := NewLoginAttemptService(config.Redis, context.Background())
+
+	// Spread over many emails, so no single account reaches its own limits
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		if err := attempts.RecordFailure(email, "203.0.113.9"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var retryErr *utils.RetryAfterError
+	if err := attempts.Check("d@example.com", "203.0.113.9"); !errors.As(err, &retryErr) || retryErr.Err != utils.ErrTooManyAttempts {
+		t.Errorf("Check from the blocked IP = %v, want ErrTooManyAttempts", err)
+	}
+	if err := attempts.Check("d@example.com", "198.51.100.1"); err != nil {
+		t.Errorf("Check from another IP = %v, want nil", err)
+	}
+}
+
+func redisTTL(t *testing.T, s *LoginAttemptService, key string) time.Duration {
+	t.Helper()
+	ttl, err := s.activeTTL(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ttl
+}
@@ -0,0 +1,484 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mentalartsapi/config"
+	"mentalartsapi/internal/cache"
+	"mentalartsapi/internal/models"
+	"mentalartsapi/internal/repository"
+	"mentalartsapi/internal/utils"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"gorm.io/gorm"
+)
+
+// OIDCService signs users in through an OpenID Connect provider using the
+// authorization code flow with PKCE and links them to local users.
+type OIDCService struct {
+	Repo        repository.UserRepository
+	RoleRepo    repository.RoleRepository
+	AuthService *AuthService
+	Client      *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]interface{}
+}
+
+// oidcDiscovery is the part of the provider's discovery document we use
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcTokenResponse is the answer of the provider's token endpoint
+type oidcTokenResponse struct {
+	IDToken string `json:"id_token"`
+	Error   string `json:"error"`
+}
+
+// oidcClaims are the ID token claims used to find or create the local user
+type oidcClaims struct {
+	Issuer            string
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+	Name              string
+	Roles             []string
+}
+
+// NewOIDCService creates a new OIDCService
+func NewOIDCService(repo repository.UserRepository, roleRepo repository.RoleRepository, authService *AuthService) *OIDCService {
+	return &OIDCService{
+		Repo:        repo,
+		RoleRepo:    roleRepo,
+		AuthService: authService,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// StartLogin remembers a new state, nonce and PKCE verifier and returns the provider URL to send the user to
+func (s *OIDCService) StartLogin() (string, error) {
+	cfg := config.OIDC
+	if !cfg.Enabled() {
+		return "", utils.ErrOIDCDisabled
+	}
+
+	provider, err := s.provider()
+	if err != nil {
+		return "", err
+	}
+
+	state, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	if err := cache.SaveOIDCState(state, cache.OIDCState{CodeVerifier: verifier, Nonce: nonce}, cfg.StateTTL); err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {cfg.ClientID},
+		"redirect_uri":          {cfg.RedirectURL},
+		"scope":                 {strings.Join(cfg.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(provider.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return provider.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// CompleteLogin exchanges the authorization code, verifies the ID token and
+// returns the linked or newly created local user
+func (s *OIDCService) CompleteLogin(code, state string) (models.User, error) {
+	if !config.OIDC.Enabled() {
+		return models.User{}, utils.ErrOIDCDisabled
+	}
+
+	saved, ok, err := cache.TakeOIDCState(state)
+	if err != nil {
+		return models.User{}, err
+	}
+	if !ok {
+		return models.User{}, utils.ErrInvalidOIDCState
+	}
+
+	idToken, err := s.exchangeCode(code, saved.CodeVerifier)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	claims, err := s.verifyIDToken(idToken, saved.Nonce)
+	if err != nil {
+		log.Println("Error verifying OIDC ID token:", err)
+		return models.User{}, utils.ErrOIDCLoginFailed
+	}
+
+	return s.linkUser(claims)
+}
+
+// exchangeCode redeems an authorization code at the token endpoint and returns the raw ID token
+func (s *OIDCService) exchangeCode(code, verifier string) (string, error) {
+	cfg := config.OIDC
+	provider, err := s.provider()
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {cfg.RedirectURL},
+		"client_id":     {cfg.ClientID},
+		"code_verifier": {verifier},
+	}
+	if cfg.ClientSecret != "" {
+		form.Set("client_secret", cfg.ClientSecret)
+	}
+
+	resp, err := s.Client.PostForm(provider.TokenEndpoint, form)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var body oidcTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK || body.IDToken == "" {
+		log.Printf("OIDC token endpoint answered %d: %s", resp.StatusCode, body.Error)
+		return "", utils.ErrOIDCLoginFailed
+	}
+	return body.IDToken, nil
+}
+
+// verifyIDToken checks the signature, issuer, audience, expiry and nonce of an ID token
+func (s *OIDCService) verifyIDToken(idToken, nonce string) (oidcClaims, error) {
+	cfg := config.OIDC
+	mapClaims := jwt.MapClaims{}
+
+	_, err := jwt.ParseWithClaims(idToken, mapClaims, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		default:
+			return nil, fmt.Errorf("unexpected signing method %q", token.Method.Alg())
+		}
+		kid, _ := token.Header["kid"].(string)
+		return s.providerKey(kid)
+	})
+	if err != nil {
+		return oidcClaims{}, err
+	}
+
+	if !mapClaims.VerifyIssuer(cfg.IssuerURL, true) {
+		return oidcClaims{}, utils.ErrInvalidIssuer
+	}
+	if !containsString(claimStrings(mapClaims["aud"]), cfg.ClientID) {
+		return oidcClaims{}, utils.ErrInvalidAudience
+	}
+	if got, _ := mapClaims["nonce"].(string); got != nonce {
+		return oidcClaims{}, fmt.Errorf("nonce mismatch")
+	}
+	// The parser only checks exp when it is present, but OpenID Connect requires it
+	if _, ok := mapClaims["exp"]; !ok {
+		return oidcClaims{}, fmt.Errorf("ID token has no expiry")
+	}
+
+	claims := oidcClaims{Issuer: cfg.IssuerURL, Roles: claimStrings(mapClaims[cfg.RoleClaim])}
+	claims.Subject, _ = mapClaims["sub"].(string)
+	claims.Email, _ = mapClaims["email"].(string)
+	claims.PreferredUsername, _ = mapClaims["preferred_username"].(string)
+	claims.Name, _ = mapClaims["name"].(string)
+	// Some providers send email_verified as the string "true"
+	switch verified := mapClaims["email_verified"].(type) {
+	case bool:
+		claims.EmailVerified = verified
+	case string:
+		claims.EmailVerified = verified == "true"
+	}
+
+	if claims.Subject == "" {
+		return oidcClaims{}, fmt.Errorf("ID token has no subject")
+	}
+	return claims, nil
+}
+
+// linkUser finds the local user for the provider identity. Unknown identities are
+// linked to an existing account with the same verified email, or get a new account.
+func (s *OIDCService) linkUser(claims oidcClaims) (models.User, error) {
+	user, err := s.Repo.GetUserByOIDCIdentity(claims.Issuer, claims.Subject)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return models.User{}, err
+	}
+	linked := err == nil
+
+	if !linked {
+		// Deleted accounts keep their identity and hold the unique index, so they are refused
+		// here instead of being linked or recreated
+		deleted, err := s.Repo.OIDCIdentityTaken(claims.Issuer, claims.Subject)
+		if err != nil {
+			return models.User{}, err
+		}
+		if deleted {
+			return models.User{}, utils.ErrAccountDisabled
+		}
+
+		if claims.Email == "" {
+			log.Println("OIDC ID token has no email claim")
+			return models.User{}, utils.ErrOIDCLoginFailed
+		}
+
+		user, err = s.Repo.GetUserByEmail(claims.Email)
+		switch {
+		case err == nil && !claims.EmailVerified:
+			// Never hand over an existing account on the strength of an unverified address
+			return models.User{}, utils.ErrEmailTaken
+		case err == nil:
+			user.OIDCIssuer = &claims.Issuer
+			user.OIDCSubject = &claims.Subject
+		case err == gorm.ErrRecordNotFound:
+			return s.createUser(claims)
+		default:
+			return models.User{}, err
+		}
+	}
+
+	if !user.IsActive {
+		return models.User{}, utils.ErrAccountDisabled
+	}
+
+	roleChanged := false
+	if roleName, mapped := s.mapRole(claims.Roles); mapped || config.OIDC.SyncRoles {
+		if roleName != user.Role.Name {
+			role, err := s.RoleRepo.GetRoleByName(roleName)
+			if err != nil {
+				return models.User{}, err
+			}
+			user.RoleID = &role.ID
+			user.Role = role
+			roleChanged = true
+		}
+	}
+
+	if claims.EmailVerified && claims.Email == user.Email && !user.EmailVerified {
+		now := time.Now()
+		user.EmailVerified = true
+		user.EmailVerifiedAt = &now
+	}
+
+	if err := s.Repo.UpdateUser(&user); err != nil {
+		return models.User{}, err
+	}
+
+	// Tokens issued before the role change still carry the old permissions
+	if roleChanged {
+		if err := s.AuthService.LogoutAll(user.ID); err != nil {
+			return models.User{}, err
+		}
+	}
+	return user, nil
+}
+
+// createUser creates a local account for a provider identity seen for the first time
+func (s *OIDCService) createUser(claims oidcClaims) (models.User, error) {
+	roleName, _ := s.mapRole(claims.Roles)
+	role, err := s.RoleRepo.GetRoleByName(roleName)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	// The address may belong to a deleted account or differ from an existing one only in case
+	if err := ensureUnique(s.Repo.EmailTaken, claims.Email, 0, utils.ErrEmailTaken); err != nil {
+		return models.User{}, err
+	}
+
+	username, err := s.availableUsername(claims)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	// The account has no password; it can only sign in through the provider
+	// until the user sets one with the password reset flow
+	user := models.User{
+		Username:      username,
+		Email:         claims.Email,
+		RoleID:        &role.ID,
+		Role:          role,
+		EmailVerified: claims.EmailVerified,
+		DisplayName:   claims.Name,
+		OIDCIssuer:    &claims.Issuer,
+		OIDCSubject:   &claims.Subject,
+	}
+	if claims.EmailVerified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	if err := s.Repo.CreateUser(&user); err != nil {
+		return models.User{}, uniqueViolation(err)
+	}
+	return user, nil
+}
+
+// mapRole returns the role for the first configured mapping found in the claim values,
+// or the default role and false when nothing matches
+func (s *OIDCService) mapRole(values []string) (string, bool) {
+	for _, mapping := range config.OIDC.RoleMapping {
+		if containsString(values, mapping.ClaimValue) {
+			return mapping.Role, true
+		}
+	}
+	return config.OIDC.DefaultRole, false
+}
+
+// availableUsername derives a username from the claims and adds a suffix when it is taken
+func (s *OIDCService) availableUsername(claims oidcClaims) (string, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base = strings.SplitN(claims.Email, "@", 2)[0]
+	}
+
+	username := base
+	for i := 0; i < 5; i++ {
+		taken, err := s.Repo.UsernameTaken(username, 0)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return username, nil
+		}
+
+		suffix, err := utils.GenerateRandomToken(3)
+		if err != nil {
+			return "", err
+		}
+		username = base + "-" + strings.ToLower(suffix)
+	}
+	return "", utils.ErrUsernameTaken
+}
+
+// provider returns the discovery document, fetching it on first use
+func (s *OIDCService) provider() (*oidcDiscovery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.discovery != nil {
+		return s.discovery, nil
+	}
+
+	var discovery oidcDiscovery
+	if err := s.getJSON(config.OIDC.IssuerURL+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != config.OIDC.IssuerURL {
+		return nil, fmt.Errorf("discovery document is for issuer %q", discovery.Issuer)
+	}
+
+	s.discovery = &discovery
+	return s.discovery, nil
+}
+
+// providerKey returns the provider's public key with the given kid.
+// The key set is fetched again when the kid is unknown, so provider key rotation is picked up.
+func (s *OIDCService) providerKey(kid string) (interface{}, error) {
+	provider, err := s.provider()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, ok := s.keys[kid]; ok {
+		return key, nil
+	}
+
+	var set utils.JWKSet
+	if err := s.getJSON(provider.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+
+	s.keys = map[string]interface{}{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			continue
+		}
+		s.keys[jwk.Kid] = key
+	}
+
+	if key, ok := s.keys[kid]; ok {
+		return key, nil
+	}
+	// Providers with a single key may leave out the kid header
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, nil
+		}
+	}
+	return nil, utils.ErrUnknownSigningKey
+}
+
+// getJSON fetches a document from the provider and decodes it into v
+func (s *OIDCService) getJSON(url string, v interface{}) error {
+	resp, err := s.Client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s answered %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// claimStrings reads a claim that may be a single string or a list of strings
+func claimStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"mentalartsapi/config"
+	"mentalartsapi/internal/cache"
+	"mentalartsapi/internal/testutil"
+	"mentalartsapi/internal/utils"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// mockIssuer is an OpenID Connect provider that issues a code for every authorization
+// it is told about and checks the PKCE verifier when the code is redeemed.
+type mockIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu     sync.Mutex
+	grants map[string]mockGrant
+}
+
+// mockGrant is what the provider remembers about an issued code
+type mockGrant struct {
+	Challenge string
+	Nonce     string
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	issuer := &mockIssuer{key: key, grants: map[string]mockGrant{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(oidcDiscovery{
+			Issuer:                issuer.server.URL,
+			AuthorizationEndpoint: issuer.server.URL + "/authorize",
+			TokenEndpoint:         issuer.server.URL + "/token",
+			JWKSURI:               issuer.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(utils.JWKSet{Keys: []utils.JWK{{
+			Kty: "RSA",
+			Kid: "test",
+			Use: "sig",
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", issuer.token)
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+// authorize plays the user's visit to the login URL and returns the code and state
+// the provider would redirect back with. A non-empty nonce replaces the requested one.
+func (m *mockIssuer) authorize(t *testing.T, loginURL, code, nonce string) (string, string) {
+	t.Helper()
+
+	parsed, err := url.Parse(loginURL)
+	if err != nil {
+		t.Fatalf("parse login URL: %v", err)
+	}
+	query := parsed.Query()
+	if query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("login URL does not ask for S256 PKCE: %s", loginURL)
+	}
+	if nonce == "" {
+		nonce = query.Get("nonce")
+	}
+
+	m.mu.Lock()
+	m.grants[code] = mockGrant{Challenge: query.Get("code_challenge"), Nonce: nonce}
+	m.mu.Unlock()
+	return code, query.Get("state")
+}
+
+// token redeems a code for a signed ID token when the PKCE verifier matches its challenge
+func (m *mockIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m.mu.Lock()
+	grant, ok := m.grants[r.PostForm.Get("code")]
+	delete(m.grants, r.PostForm.Get("code"))
+	m.mu.Unlock()
+
+	verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(verifier[:]) != grant.Challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(oidcTokenResponse{Error: "invalid_grant"})
+		return
+	}
+
+	signed, err := m.sign(m.idTokenClaims(r.PostForm.Get("client_id"), grant.Nonce))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(oidcTokenResponse{IDToken: signed})
+}
+
+// idTokenClaims are the claims of a valid ID token for the client and nonce
+func (m *mockIssuer) idTokenClaims(clientID, nonce string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            m.server.URL,
+		"aud":            clientID,
+		"sub":            "user-1",
+		"email":          "jane@example.com",
+		"email_verified": true,
+		"nonce":          nonce,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Minute).Unix(),
+	}
+}
+
+// sign signs ID token claims with the provider's published key
+func (m *mockIssuer) sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test"
+	return token.SignedString(m.key)
+}
+
+// newTestOIDCService configures OIDC against the mock issuer
+func newTestOIDCService(t *testing.T, issuer *mockIssuer) *OIDCService {
+	t.Helper()
+
+	previous := config.OIDC
+	config.OIDC = config.OIDCConfig{
+		IssuerURL:   issuer.server.URL,
+		ClientID:    "mentalartsapi",
+		RedirectURL: "http://localhost:8000/api/v1/auth/oidc/callback",
+		Scopes:      []string{"openid", "email"},
+		StateTTL:    time.Minute,
+		DefaultRole: utils.RoleUser,
+	}
+	t.Cleanup(func() { config.OIDC = previous })
+
+	return &OIDCService{Client: issuer.server.Client()}
+}
+
+func TestOIDCCompleteLoginRejectsMismatches(t *testing.T) {
+	testutil.UseFakeRedis(t)
+	issuer := newMockIssuer(t)
+	service := newTestOIDCService(t, issuer)
+
+	startLogin := func(t *testing.T) string {
+		t.Helper()
+		loginURL, err := service.StartLogin()
+		if err != nil {
+			t.Fatalf("StartLogin: %v", err)
+		}
+		return loginURL
+	}
+
+	tests := []struct {
+		name string
+		// login returns the code and state the callback receives
+		login   func(t *testing.T) (string, string)
+		wantErr error
+	}{
+		{
+			name: "unknown state",
+			login: func(t *testing.T) (string, string) {
+				code, _ := issuer.authorize(t, startLogin(t), "code-unknown-state", "")
+				return code, "forged-state"
+			},
+			wantErr: utils.ErrInvalidOIDCState,
+		},
+		{
+			name: "state used twice",
+			login: func(t *testing.T) (string, string) {
+				code, state := issuer.authorize(t, startLogin(t), "code-replayed", "other-nonce")
+				if _, err := service.CompleteLogin(code, state); err != utils.ErrOIDCLoginFailed {
+					t.Fatalf("first callback: got %v, want %v", err, utils.ErrOIDCLoginFailed)
+				}
+				return code, state
+			},
+			wantErr: utils.ErrInvalidOIDCState,
+		},
+		{
+			name: "nonce mismatch",
+			login: func(t *testing.T) (string, string) {
+				return issuer.authorize(t, startLogin(t), "code-nonce", "other-nonce")
+			},
+			wantErr: utils.ErrOIDCLoginFailed,
+		},
+		{
+			name: "PKCE verifier of another login",
+			login: func(t *testing.T) (string, string) {
+				code, _ := issuer.authorize(t, startLogin(t), "code-pkce", "")
+				_, otherState := issuer.authorize(t, startLogin(t), "code-pkce-other", "")
+				return code, otherState
+			},
+			wantErr: utils.ErrOIDCLoginFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, state := tt.login(t)
+			if _, err := service.CompleteLogin(code, state); err != tt.wantErr {
+				t.Errorf("CompleteLogin: got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOIDCMatchingLoginVerifies(t *testing.T) {
+	testutil.UseFakeRedis(t)
+	issuer := newMockIssuer(t)
+	service := newTestOIDCService(t, issuer)
+
+	loginURL, err := service.StartLogin()
+	if err != nil {
+		t.Fatalf("StartLogin: %v", err)
+	}
+	code, state := issuer.authorize(t, loginURL, "code-valid", "")
+
+	saved, ok, err := cache.TakeOIDCState(state)
+	if err != nil || !ok {
+		t.Fatalf("state was not saved: ok=%v err=%v", ok, err)
+	}
+	idToken, err := service.exchangeCode(code, saved.CodeVerifier)
+	if err != nil {
+		t.Fatalf("exchangeCode: %v", err)
+	}
+	claims, err := service.verifyIDToken(idToken, saved.Nonce)
+	if err != nil {
+		t.Fatalf("verifyIDToken: %v", err)
+	}
+	if claims.Subject != "user-1" || claims.Email != "jane@example.com" || !claims.EmailVerified {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+}
+
+func TestOIDCVerifyIDTokenRequiresExpiry(t *testing.T) {
+	issuer := newMockIssuer(t)
+	service := newTestOIDCService(t, issuer)
+
+	valid := issuer.idTokenClaims("mentalartsapi", "nonce")
+	withoutExp := issuer.idTokenClaims("mentalartsapi", "nonce")
+	delete(withoutExp, "exp")
+	expired := issuer.idTokenClaims("mentalartsapi", "nonce")
+	expired["exp"] = time.Now().Add(-time.Minute).Unix()
+
+	for name, claims := range map[string]jwt.MapClaims{"without exp": withoutExp, "expired": expired} {
+		idToken, err := issuer.sign(claims)
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		if _, err := service.verifyIDToken(idToken, "nonce"); err == nil {
+			t.Errorf("ID token %s was accepted", name)
+		}
+	}
+
+	idToken, err := issuer.sign(valid)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if _, err := service.verifyIDToken(idToken, "nonce"); err != nil {
+		t.Errorf("valid ID token was rejected: %v", err)
+	}
+}
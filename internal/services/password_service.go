@@ -0,0 +1,160 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"mentalartsapi/config"
+	"mentalartsapi/internal/cache"
+	"mentalartsapi/internal/mailer"
+	"mentalartsapi/internal/models"
+	"mentalartsapi/internal/utils"
+	"net/url"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ForgotPassword mails a time-limited reset link to the user.
+// Unknown addresses are ignored so the endpoint cannot be used to probe accounts.
+func (s *AuthService) ForgotPassword(email string) error {
+	user, err := s.Repo.GetUserByEmail(email)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return err
+	}
+
+	allowed, err := cache.AcquireCooldown(fmt.Sprintf("password-reset:%d", user.ID), time.Minute)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		log.Printf("Password reset email for user %d skipped, cooldown active", user.ID)
+		return nil
+	}
+
+	// Only the most recent link should work
+	if err := s.ResetRepo.InvalidateTokensForUser(user.ID); err != nil {
+		return err
+	}
+
+	rawToken, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	resetToken := models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(rawToken),
+		ExpiresAt: time.Now().Add(config.Auth.PasswordResetTTL),
+	}
+	if err := s.ResetRepo.CreateToken(&resetToken); err != nil {
+		return err
+	}
+
+	return s.Mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello %s,\n\nSomeone asked to reset the password of your account. %s\n\nThe token expires in %s. If you did not ask for this, you can ignore this email.\n",
+			user.Username, resetInstructions(rawToken), config.Auth.PasswordResetTTL),
+	})
+}
+
+// resetInstructions tells the user how to use a reset token. The API only accepts the token
+// in a POST, so a link is sent only when a front-end page is configured to collect the new password.
+func resetInstructions(rawToken string) string {
+	if config.Auth.PasswordResetURL == "" {
+		return fmt.Sprintf("Use the token below with POST %s/api/v1/auth/reset-password to choose a new password:\n\n%s", config.Auth.AppBaseURL, rawToken)
+	}
+
+	// The URL is validated when the configuration is loaded
+	link, _ := url.Parse(config.Auth.PasswordResetURL)
+	query := link.Query()
+	query.Set("token", rawToken)
+	link.RawQuery = query.Encode()
+	return fmt.Sprintf("Open the link below to choose a new password:\n\n%s\n\nReset token: %s", link, rawToken)
+}
+
+// ResetPassword sets a new password with a reset token and signs the user out everywhere
+func (s *AuthService) ResetPassword(rawToken, newPassword string) error {
+	resetToken, err := s.ResetRepo.GetTokenByHash(utils.HashToken(rawToken))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return utils.ErrInvalidActionToken
+		}
+		return err
+	}
+	if resetToken.UsedAt != nil || time.Now().After(resetToken.ExpiresAt) {
+		return utils.ErrInvalidActionToken
+	}
+
+	user, err := s.Repo.GetUserByID(resetToken.UserID)
+	if err != nil {
+		return err
+	}
+
+	// Check the policy before using up the token so the user can try another password
+	if err := config.PasswordPolicy.Validate(newPassword, user.Username, user.Email); err != nil {
+		return err
+	}
+
+	consumed, err := s.ResetRepo.MarkTokenUsed(resetToken.ID)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return utils.ErrInvalidActionToken
+	}
+
+	hashedPassword, err := config.Passwords.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+	user.Password = hashedPassword
+
+	// Receiving the reset email proves the user owns the address
+	if !user.EmailVerified {
+		now := time.Now()
+		user.EmailVerified = true
+		user.EmailVerifiedAt = &now
+	}
+
+	if err := s.Repo.UpdateUser(&user); err != nil {
+		return err
+	}
+
+	if err := s.ResetRepo.InvalidateTokensForUser(user.ID); err != nil {
+		return err
+	}
+	return s.LogoutAll(user.ID)
+}
+
+// ChangePassword replaces the password of a logged in user after checking the current one
+func (s *AuthService) ChangePassword(userID uint, currentPassword, newPassword string) error {
+	user, err := s.Repo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if ok, _, err := config.Passwords.Verify(user.Password, currentPassword); err != nil || !ok {
+		return utils.ErrIncorrectPassword
+	}
+
+	if err := config.PasswordPolicy.Validate(newPassword, user.Username, user.Email); err != nil {
+		return err
+	}
+
+	hashedPassword, err := config.Passwords.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+	user.Password = hashedPassword
+
+	if err := s.Repo.UpdateUser(&user); err != nil {
+		return err
+	}
+
+	// A pending reset link must not be able to undo the change
+	return s.ResetRepo.InvalidateTokensForUser(user.ID)
+}
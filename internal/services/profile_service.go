@@ -0,0 +1,120 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"mentalartsapi/internal/dto"
+	"mentalartsapi/internal/models"
+	"mentalartsapi/internal/utils"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// GetProfile returns the profile of the current user
+func (s *UserService) GetProfile(userID uint) (dto.ProfileResponseDTO, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return dto.ProfileResponseDTO{}, err
+	}
+	return toProfileResponseDTO(user), nil
+}
+
+// UpdateProfile applies the given changes to the current user's profile.
+// Changing the email address marks it as unverified and sends a new verification email.
+func (s *UserService) UpdateProfile(userID uint, req dto.UpdateProfileRequestDTO) (dto.ProfileResponseDTO, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return dto.ProfileResponseDTO{}, err
+	}
+
+	if req.Username != nil && *req.Username != user.Username {
+		if err := ensureUnique(s.Repo.UsernameTaken, *req.Username, user.ID, utils.ErrUsernameTaken); err != nil {
+			return dto.ProfileResponseDTO{}, err
+		}
+		user.Username = *req.Username
+	}
+
+	emailChanged := false
+	if req.Email != nil && !strings.EqualFold(*req.Email, user.Email) {
+		if err := ensureUnique(s.Repo.EmailTaken, *req.Email, user.ID, utils.ErrEmailTaken); err != nil {
+			return dto.ProfileResponseDTO{}, err
+		}
+		user.Email = *req.Email
+		user.EmailVerified = false
+		user.EmailVerifiedAt = nil
+		emailChanged = true
+	}
+
+	if req.DisplayName != nil {
+		user.DisplayName = strings.TrimSpace(*req.DisplayName)
+	}
+	if req.Locale != nil {
+		user.Locale = *req.Locale
+	}
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil {
+			return dto.ProfileResponseDTO{}, utils.ErrBadRequest
+		}
+		user.Timezone = *req.Timezone
+	}
+
+	if err := s.Repo.UpdateUser(&user); err != nil {
+		return dto.ProfileResponseDTO{}, uniqueViolation(err)
+	}
+
+	if emailChanged {
+		// The change is saved either way; the user can ask for a new link later
+		if err := s.AuthService.SendVerificationEmail(user); err != nil {
+			log.Println("Error sending verification email:", err)
+		}
+	}
+
+	return toProfileResponseDTO(user), nil
+}
+
+// ensureUnique returns conflictErr if another user than exceptID already holds value
+func ensureUnique(taken func(string, uint) (bool, error), value string, exceptID uint, conflictErr error) error {
+	exists, err := taken(value, exceptID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return conflictErr
+	}
+	return nil
+}
+
+// uniqueViolation maps a unique index violation on the users table to the matching conflict error,
+// for the rare case where a concurrent request took the value after ensureUnique checked it.
+func uniqueViolation(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "23505" {
+		return err
+	}
+	switch {
+	case strings.Contains(pgErr.ConstraintName, "email"):
+		return utils.ErrEmailTaken
+	case strings.Contains(pgErr.ConstraintName, "username"):
+		return utils.ErrUsernameTaken
+	}
+	return err
+}
+
+// toProfileResponseDTO maps a user to the profile the user sees of themselves
+func toProfileResponseDTO(user models.User) dto.ProfileResponseDTO {
+	return dto.ProfileResponseDTO{
+		ID:               user.ID,
+		Username:         user.Username,
+		Email:            user.Email,
+		EmailVerified:    user.EmailVerified,
+		TwoFactorEnabled: user.TOTPEnabled,
+		Role:             user.Role.Name,
+		Permissions:      user.Role.PermissionNames(),
+		DisplayName:      user.DisplayName,
+		Locale:           user.Locale,
+		Timezone:         user.Timezone,
+		CreatedAt:        user.CreatedAt,
+	}
+}
@@ -0,0 +1,293 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"mentalartsapi/config"
+	"mentalartsapi/internal/repository"
+	"mentalartsapi/internal/utils"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// duplicateMinLength is the length below which texts such as "Great book!" may repeat
+const duplicateMinLength = 30
+
+// ReviewContent is the text of a review or a reply about to be saved
+type ReviewContent struct {
+	UserID    uint
+	ReviewID  uint // Zero for a new review
+	Reply     bool // The text is a reply rather than a review
+	ReplyID   uint // Zero for a new reply
+	Moderator bool // Written by a moderator, who is exempt from the duplicate and rate checks
+	Comment   string
+}
+
+// isEdit reports whether the content replaces the text of an existing review or reply
+func (c ReviewContent) isEdit() bool {
+	if c.Reply {
+		return c.ReplyID != 0
+	}
+	return c.ReviewID != 0
+}
+
+// subject names what the content is in messages to its author
+func (c ReviewContent) subject() string {
+	if c.Reply {
+		return "reply"
+	}
+	return "review"
+}
+
+// FilterFinding describes what a content filter objected to
+type FilterFinding struct {
+	Reason     string        // Shown to the author
+	RetryAfter time.Duration // Set when the same content would pass later
+}
+
+// ReviewFilter checks review content before it is saved
+type ReviewFilter interface {
+	// Check returns nil when the content passes
+	Check(content ReviewContent) (*FilterFinding, error)
+}
+
+// ReviewMasker is implemented by filters that can remove what they found from the text
+type ReviewMasker interface {
+	Mask(comment string) string
+}
+
+// ReviewCounter is implemented by filters that count the content they let through
+type ReviewCounter interface {
+	// Uncount takes back the count of content that was not saved after all
+	Uncount(content ReviewContent) error
+}
+
+// FilterStep is a filter with the action taken when it finds something
+type FilterStep struct {
+	Filter ReviewFilter
+	Action string // utils.FilterOff, FilterMask, FilterFlag or FilterReject
+}
+
+// ReviewFilterPipeline runs content filters over new and edited reviews and replies in order.
+// Each filter sees the text as masked by the filters before it.
+type ReviewFilterPipeline struct {
+	Steps []FilterStep
+}
+
+// FilterResult is the review content after all filters ran
+type FilterResult struct {
+	Comment string
+	Flags   []string // Why the review has to wait for a moderator
+
+	counted []ReviewCounter // Filters that counted the content
+	content ReviewContent
+}
+
+// Discard takes back what the filters counted, for content that could not be saved
+func (r FilterResult) Discard() {
+	for _, counter := range r.counted {
+		if err := counter.Uncount(r.content); err != nil {
+			log.Println("Error discarding filtered content:", err)
+		}
+	}
+}
+
+// Flagged reports whether a filter held the review for moderation
+func (r FilterResult) Flagged() bool {
+	return len(r.Flags) > 0
+}
+
+// ModerationReason explains to the author why a flagged review is not published yet
+func (r FilterResult) ModerationReason() string {
+	return "Held for moderation: " + strings.Join(r.Flags, "; ")
+}
+
+// NewReviewFilterPipeline creates the pipeline of built-in filters configured by cfg.
+// The rate check runs last so that reviews refused by another filter do not count.
+func NewReviewFilterPipeline(cfg config.ReviewFilterConfig, repo repository.ReviewRepository, replyRepo repository.ReviewReplyRepository, cache *redis.Client, ctx context.Context) *ReviewFilterPipeline {
+	return &ReviewFilterPipeline{Steps: []FilterStep{
+		{Filter: &WordFilter{Words: utils.NewWordList(cfg.Words)}, Action: cfg.WordsAction},
+		{Filter: &ContactFilter{}, Action: cfg.LinksAction},
+		{Filter: &DuplicateFilter{Repo: repo, Replies: replyRepo}, Action: cfg.DuplicateAction},
+		{Filter: &RateFilter{Cache: cache, Ctx: ctx, Limit: cfg.RateLimit, Window: cfg.RateWindow}, Action: cfg.RateAction},
+	}}
+}
+
+// Run filters the content. A rejection is returned as a *utils.ContentRejectedError,
+// wrapped in a *utils.RetryAfterError when the content would pass later.
+// Callers that fail to save the content afterwards call Discard on the result.
+func (p *ReviewFilterPipeline) Run(content ReviewContent) (FilterResult, error) {
+	result := FilterResult{Comment: content.Comment, content: content}
+	if p == nil {
+		return result, nil
+	}
+
+	for _, step := range p.Steps {
+		if step.Action == utils.FilterOff {
+			continue
+		}
+
+		content.Comment = result.Comment
+		finding, err := step.Filter.Check(content)
+		if err != nil {
+			result.Discard()
+			return FilterResult{}, err
+		}
+		if finding == nil {
+			if counter, ok := step.Filter.(ReviewCounter); ok {
+				result.counted = append(result.counted, counter)
+			}
+			continue
+		}
+
+		masker, canMask := step.Filter.(ReviewMasker)
+		switch {
+		case step.Action == utils.FilterReject:
+			result.Discard()
+			rejected := &utils.ContentRejectedError{Subject: content.subject(), Reason: finding.Reason}
+			if finding.RetryAfter > 0 {
+				return FilterResult{}, &utils.RetryAfterError{Err: rejected, RetryAfter: finding.RetryAfter}
+			}
+			return FilterResult{}, rejected
+		case step.Action == utils.FilterMask && canMask:
+			result.Comment = masker.Mask(result.Comment)
+		default:
+			result.Flags = append(result.Flags, finding.Reason)
+		}
+	}
+	return result, nil
+}
+
+// WordFilter finds blocked words
+type WordFilter struct {
+	Words *utils.WordList
+}
+
+func (f *WordFilter) Check(content ReviewContent) (*FilterFinding, error) {
+	if len(f.Words.Find(content.Comment)) == 0 {
+		return nil, nil
+	}
+	return &FilterFinding{Reason: "contains inappropriate language"}, nil
+}
+
+// Mask replaces the letters of blocked words with asterisks
+func (f *WordFilter) Mask(comment string) string {
+	return f.Words.Mask(comment)
+}
+
+// ContactFilter finds links and phone numbers, which are mostly spam in reviews
+type ContactFilter struct{}
+
+func (f *ContactFilter) Check(content ReviewContent) (*FilterFinding, error) {
+	found := utils.FindContact(content.Comment)
+	if len(found) == 0 {
+		return nil, nil
+	}
+	return &FilterFinding{Reason: "contains " + strings.Join(found, " and ")}, nil
+}
+
+// Mask replaces links and phone numbers with placeholders
+func (f *ContactFilter) Mask(comment string) string {
+	return utils.MaskContact(comment)
+}
+
+// DuplicateFilter finds text the user already posted in another of their reviews,
+// or for replies in another of their replies
+type DuplicateFilter struct {
+	Repo    repository.ReviewRepository
+	Replies repository.ReviewReplyRepository
+}
+
+func (f *DuplicateFilter) Check(content ReviewContent) (*FilterFinding, error) {
+	text := utils.NormalizeText(content.Comment)
+	if content.Moderator || utf8.RuneCountInString(text) < duplicateMinLength {
+		return nil, nil
+	}
+
+	if content.Reply {
+		replies, err := f.Replies.GetRepliesByUser(content.UserID)
+		if err != nil {
+			return nil, err
+		}
+		for _, reply := range replies {
+			if reply.ID != content.ReplyID && utils.NormalizeText(reply.Comment) == text {
+				return &FilterFinding{Reason: "repeats the text of another of your replies"}, nil
+			}
+		}
+		return nil, nil
+	}
+
+	reviews, err := f.Repo.GetReviewsByUser(content.UserID, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, review := range reviews {
+		if review.ID != content.ReviewID && utils.NormalizeText(review.Comment) == text {
+			return &FilterFinding{Reason: "repeats the text of another of your reviews"}, nil
+		}
+	}
+	return nil, nil
+}
+
+// RateFilter limits how many reviews a user can post within a time window.
+// Replies are counted separately against the same limit; edits, moderators' texts
+// and content refused by this filter are not counted.
+type RateFilter struct {
+	Cache  *redis.Client // Redis client
+	Ctx    context.Context
+	Limit  int
+	Window time.Duration
+}
+
+func (f *RateFilter) Check(content ReviewContent) (*FilterFinding, error) {
+	if content.isEdit() || content.Moderator || f.Limit <= 0 {
+		return nil, nil
+	}
+
+	key := f.key(content)
+	count, err := f.Cache.Incr(f.Ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	if count == 1 {
+		f.Cache.Expire(f.Ctx, key, f.Window)
+	}
+	if count <= int64(f.Limit) {
+		return nil, nil
+	}
+	if err := f.Cache.Decr(f.Ctx, key).Err(); err != nil {
+		return nil, err
+	}
+
+	ttl, err := f.Cache.TTL(f.Ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	if ttl <= 0 { // The first request failed to set the expiry
+		f.Cache.Expire(f.Ctx, key, f.Window)
+		ttl = f.Window
+	}
+	return &FilterFinding{Reason: "too many " + content.subject() + "s posted recently", RetryAfter: ttl}, nil
+}
+
+// Uncount gives back the slot taken by content that was not saved
+func (f *RateFilter) Uncount(content ReviewContent) error {
+	key := f.key(content)
+	count, err := f.Cache.Decr(f.Ctx, key).Result()
+	if err != nil {
+		return err
+	}
+	if count < 0 { // The window ended in between; do not leave a counter without expiry
+		return f.Cache.Del(f.Ctx, key).Err()
+	}
+	return nil
+}
+
+// key is the counter of the user's new reviews or replies
+func (f *RateFilter) key(content ReviewContent) string {
+	return fmt.Sprintf("%s_rate:%d", content.subject(), content.UserID)
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"mentalartsapi/config"
+	"mentalartsapi/internal/testutil"
+	"mentalartsapi/internal/utils"
+)
+
+// ratePipeline returns a pipeline that masks blocked words and allows two new texts per user
+func ratePipeline(t *testing.T) (*ReviewFilterPipeline, *testutil.FakeRedis) {
+	server := testutil.UseFakeRedis(t)
+	return &ReviewFilterPipeline{Steps: []FilterStep{
+		{Filter: &WordFilter{Words: utils.NewWordList(nil)}, Action: utils.FilterMask},
+		{Filter: &RateFilter{Cache: config.Redis, Ctx: context.Background(), Limit: 2, Window: time.Hour}, Action: utils.FilterReject},
+	}}, server
+}
+
+func TestRateFilterCountsOnlyKeptContent(t *testing.T) {
+	pipeline, server := ratePipeline(t)
+	review := ReviewContent{UserID: 7, Comment: "A fine book"}
+
+	if _, err := pipeline.Run(review); err != nil {
+		t.Fatalf("first review: %v", err)
+	}
+	discarded, err := pipeline.Run(review)
+	if err != nil {
+		t.Fatalf("second review: %v", err)
+	}
+	discarded.Discard() // Saving the second review failed
+
+	if _, err := pipeline.Run(review); err != nil {
+		t.Fatalf("a discarded review still used up the quota: %v", err)
+	}
+
+	_, err = pipeline.Run(review)
+	var retry *utils.RetryAfterError
+	if !errors.As(err, &retry) {
+		t.Fatalf("err = %v, want a *utils.RetryAfterError", err)
+	}
+	if retry.RetryAfter != time.Hour {
+		t.Errorf("RetryAfter = %v, want the window", retry.RetryAfter)
+	}
+	if got, _ := server.Value("review_rate:7"); got != "2" {
+		t.Errorf("counter = %s after a refused review, want 2", got)
+	}
+
+	// Replies have their own counter
+	if _, err := pipeline.Run(ReviewContent{UserID: 7, Reply: true, ReviewID: 1, Comment: "Agreed"}); err != nil {
+		t.Errorf("reply refused because of the review quota: %v", err)
+	}
+}
+
+func TestModeratorEditsAreFilteredButNotCounted(t *testing.T) {
+	pipeline, server := ratePipeline(t)
+
+	result, err := pipeline.Run(ReviewContent{UserID: 3, ReviewID: 5, Moderator: true, Comment: "What a shit ending"})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.Comment != "What a **** ending" {
+		t.Errorf("Comment = %q, want the blocked word masked", result.Comment)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := pipeline.Run(ReviewContent{UserID: 3, Moderator: true, Comment: "Moderator note"}); err != nil {
+			t.Fatalf("moderator text %d was rate limited: %v", i+1, err)
+		}
+	}
+	if _, counted := server.Value("review_rate:3"); counted {
+		t.Error("moderator texts were counted")
+	}
+}
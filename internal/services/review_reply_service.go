@@ -0,0 +1,301 @@
+package services
+
+import (
+	"mentalartsapi/config"
+	"mentalartsapi/internal/dto"
+	"mentalartsapi/internal/models"
+	"mentalartsapi/internal/repository"
+	"mentalartsapi/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReviewReplyService manages threaded replies on reviews.
+// Replies follow the ownership and moderation rules of reviews.
+type ReviewReplyService struct {
+	Repo    repository.ReviewReplyRepository
+	Reviews *ReviewService
+}
+
+// NewReviewReplyService creates a new ReviewReplyService
+func NewReviewReplyService(repo repository.ReviewReplyRepository, reviews *ReviewService) *ReviewReplyService {
+	return &ReviewReplyService{Repo: repo, Reviews: reviews}
+}
+
+// GetThread returns the replies of a review as a tree, oldest first at every level.
+// Unpublished replies are only included for their author and moderators,
+// and replies below a reply the actor cannot see are left out.
+func (s *ReviewReplyService) GetThread(reviewID uint, actor ReviewActor) ([]dto.ReplyResponseDTO, error) {
+	if _, err := s.Reviews.GetReview(reviewID, actor); err != nil {
+		return nil, err
+	}
+
+	replies, err := s.Repo.GetRepliesForReview(reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[uint][]models.ReviewReply) // Keyed by parent ID, 0 for direct replies
+	for _, reply := range replies {
+		if reply.Status != models.ReviewApproved && !canManageReply(reply, actor) {
+			continue
+		}
+		var parentID uint
+		if reply.ParentID != nil {
+			parentID = *reply.ParentID
+		}
+		children[parentID] = append(children[parentID], reply)
+	}
+
+	return buildThread(children, 0), nil
+}
+
+// buildThread maps the replies below parentID and their descendants to DTOs
+func buildThread(children map[uint][]models.ReviewReply, parentID uint) []dto.ReplyResponseDTO {
+	thread := make([]dto.ReplyResponseDTO, 0, len(children[parentID]))
+	for _, reply := range children[parentID] {
+		replyDTO := toReplyResponse(reply)
+		replyDTO.Replies = buildThread(children, reply.ID)
+		replyDTO.ReplyCount = len(replyDTO.Replies)
+		thread = append(thread, replyDTO)
+	}
+	return thread
+}
+
+// CreateReply adds a user's reply to a published review, or to a published reply of it
+// when req.ParentID is set. Replies nested deeper than the configured limit fail with
+// utils.ErrReplyTooDeep. The text passes the review content filters first; with
+// pre-moderation, or when a filter flags it, the reply waits for approval.
+func (s *ReviewReplyService) CreateReply(reviewID, userID uint, req dto.CreateReplyRequestDTO) (dto.ReplyResponseDTO, error) {
+	review, err := s.Reviews.Repo.GetReviewByID(reviewID)
+	if err != nil || review.Status != models.ReviewApproved {
+		return dto.ReplyResponseDTO{}, utils.ErrNotFound
+	}
+
+	depth := 1
+	if req.ParentID != nil {
+		parent, err := s.Repo.GetReplyByID(*req.ParentID)
+		if err != nil || parent.ReviewID != reviewID || parent.Status != models.ReviewApproved {
+			return dto.ReplyResponseDTO{}, utils.ErrNotFound
+		}
+		depth = parent.Depth + 1
+	}
+	if depth > config.Reviews.MaxReplyDepth {
+		return dto.ReplyResponseDTO{}, utils.ErrReplyTooDeep
+	}
+
+	filtered, err := s.Reviews.Filters.Run(ReviewContent{UserID: userID, ReviewID: reviewID, Reply: true, Comment: req.Comment})
+	if err != nil {
+		return dto.ReplyResponseDTO{}, err
+	}
+
+	status := models.ReviewApproved
+	if config.Reviews.PreModeration {
+		status = models.ReviewPending
+	}
+
+	reply := models.ReviewReply{
+		ReviewID: reviewID,
+		ParentID: req.ParentID,
+		Depth:    depth,
+		UserID:   userID,
+		Comment:  filtered.Comment,
+		Status:   status,
+	}
+	if filtered.Flagged() {
+		reply.Status = models.ReviewPending
+		reply.ModerationReason = filtered.ModerationReason()
+	}
+	if err := s.Repo.CreateReply(&reply); err != nil {
+		filtered.Discard()
+		return dto.ReplyResponseDTO{}, err
+	}
+
+	// Fetch the created reply with its author
+	reply, err = s.Repo.GetReplyByID(reply.ID)
+	if err != nil {
+		return dto.ReplyResponseDTO{}, err
+	}
+	return toReplyResponse(reply), nil
+}
+
+// UpdateReply changes the text of a reply.
+// Only the author may change a reply unless the caller is a moderator.
+// Edits pass the content filters and are queued again like review edits;
+// moderators skip only the duplicate and rate checks.
+func (s *ReviewReplyService) UpdateReply(id uint, actor ReviewActor, req dto.UpdateReplyRequestDTO) (dto.ReplyResponseDTO, error) {
+	reply, err := s.Repo.GetReplyByID(id)
+	if err != nil {
+		return dto.ReplyResponseDTO{}, utils.ErrNotFound
+	}
+	if !canManageReply(reply, actor) {
+		return dto.ReplyResponseDTO{}, utils.ErrForbidden
+	}
+
+	filtered, err := s.Reviews.Filters.Run(ReviewContent{UserID: actor.UserID, ReviewID: reply.ReviewID, Reply: true, ReplyID: reply.ID, Moderator: actor.Moderator, Comment: req.Comment})
+	if err != nil {
+		return dto.ReplyResponseDTO{}, err
+	}
+
+	reply.Comment = filtered.Comment
+	if filtered.Flagged() && reply.Status != models.ReviewHidden {
+		reply.Status = models.ReviewPending
+		reply.ModerationReason = filtered.ModerationReason()
+	} else if !actor.Moderator && (reply.Status == models.ReviewRejected || (config.Reviews.PreModeration && reply.Status == models.ReviewApproved)) {
+		reply.Status = models.ReviewPending
+	}
+
+	if err := s.Repo.UpdateReply(&reply); err != nil {
+		return dto.ReplyResponseDTO{}, err
+	}
+	return toReplyResponse(reply), nil
+}
+
+// DeleteReply deletes a reply together with the replies below it.
+// Only the author may delete a reply unless the caller is a moderator.
+func (s *ReviewReplyService) DeleteReply(id uint, actor ReviewActor) error {
+	reply, err := s.Repo.GetReplyByID(id)
+	if err != nil {
+		return utils.ErrNotFound
+	}
+	if !canManageReply(reply, actor) {
+		return utils.ErrForbidden
+	}
+
+	replies, err := s.Repo.GetRepliesForReview(reply.ReviewID)
+	if err != nil {
+		return err
+	}
+
+	// Collect the subtree level by level; a reply always comes after its parent
+	ids := []uint{reply.ID}
+	deleted := map[uint]bool{reply.ID: true}
+	for _, r := range replies {
+		if r.ParentID != nil && deleted[*r.ParentID] {
+			ids = append(ids, r.ID)
+			deleted[r.ID] = true
+		}
+	}
+
+	return s.Repo.DeleteReplies(ids)
+}
+
+// EmbedReplies adds the number of published direct replies to each review and,
+// when limit is positive, the first limit of those replies
+func (s *ReviewReplyService) EmbedReplies(reviews []dto.ReviewResponseDTO, limit int) error {
+	if len(reviews) == 0 {
+		return nil
+	}
+
+	reviewIDs := make([]uint, 0, len(reviews))
+	for _, review := range reviews {
+		reviewIDs = append(reviewIDs, review.ID)
+	}
+
+	counts, err := s.Repo.CountReplies("review_id", reviewIDs)
+	if err != nil {
+		return err
+	}
+
+	firstReplies := make(map[uint][]dto.ReplyResponseDTO)
+	if limit > 0 {
+		replies, err := s.Repo.GetFirstReplies(reviewIDs, limit)
+		if err != nil {
+			return err
+		}
+
+		replyIDs := make([]uint, 0, len(replies))
+		for _, reply := range replies {
+			replyIDs = append(replyIDs, reply.ID)
+		}
+		var replyCounts map[uint]int
+		if len(replyIDs) > 0 {
+			if replyCounts, err = s.Repo.CountReplies("parent_id", replyIDs); err != nil {
+				return err
+			}
+		}
+
+		for _, reply := range replies {
+			replyDTO := toReplyResponse(reply)
+			replyDTO.ReplyCount = replyCounts[reply.ID]
+			firstReplies[reply.ReviewID] = append(firstReplies[reply.ReviewID], replyDTO)
+		}
+	}
+
+	for i := range reviews {
+		count := counts[reviews[i].ID]
+		reviews[i].ReplyCount = &count
+		reviews[i].Replies = firstReplies[reviews[i].ID]
+	}
+	return nil
+}
+
+// GetModerationQueue lists the replies waiting for a moderator, oldest first
+func (s *ReviewReplyService) GetModerationQueue(page, pageSize int) (dto.ReplyListResponseDTO, error) {
+	replies, total, err := s.Repo.GetRepliesByStatus(models.ReviewPending, page, pageSize)
+	if err != nil {
+		return dto.ReplyListResponseDTO{}, err
+	}
+
+	replyDTOs := make([]dto.ReplyResponseDTO, 0, len(replies))
+	for _, reply := range replies {
+		replyDTOs = append(replyDTOs, toReplyResponse(reply))
+	}
+
+	return dto.ReplyListResponseDTO{
+		Replies:  replyDTOs,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	}, nil
+}
+
+// ModerateReply records a moderator's decision on a reply.
+// Rejecting or hiding a reply requires a reason, which is shown to its author.
+func (s *ReviewReplyService) ModerateReply(id, moderatorID uint, status, reason string) (dto.ReplyResponseDTO, error) {
+	if status != models.ReviewApproved && reason == "" {
+		return dto.ReplyResponseDTO{}, utils.ErrBadRequest
+	}
+
+	reply, err := s.Repo.GetReplyByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return dto.ReplyResponseDTO{}, utils.ErrNotFound
+		}
+		return dto.ReplyResponseDTO{}, err
+	}
+
+	now := time.Now()
+	reply.Status = status
+	reply.ModerationReason = reason
+	reply.ModeratedByID = &moderatorID
+	reply.ModeratedAt = &now
+
+	if err := s.Repo.UpdateReply(&reply); err != nil {
+		return dto.ReplyResponseDTO{}, err
+	}
+	return toReplyResponse(reply), nil
+}
+
+// canManageReply reports whether a user may see an unpublished reply, edit it or delete it
+func canManageReply(reply models.ReviewReply, actor ReviewActor) bool {
+	return actor.Moderator || reply.UserID == actor.UserID
+}
+
+// toReplyResponse maps a reply with its user preloaded to a DTO without nested replies
+func toReplyResponse(reply models.ReviewReply) dto.ReplyResponseDTO {
+	return dto.ReplyResponseDTO{
+		ID:               reply.ID,
+		ReviewID:         reply.ReviewID,
+		ParentID:         reply.ParentID,
+		Depth:            reply.Depth,
+		UserID:           reply.UserID,
+		Username:         reply.User.Username,
+		Comment:          reply.Comment,
+		Status:           reply.Status,
+		ModerationReason: reply.ModerationReason,
+		CreatedAt:        reply.CreatedAt,
+		UpdatedAt:        reply.UpdatedAt,
+	}
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"fmt"
+	"mentalartsapi/config"
+	"mentalartsapi/internal/dto"
+	"mentalartsapi/internal/models"
+	"mentalartsapi/internal/repository"
+	"mentalartsapi/internal/utils"
+)
+
+// ReviewReportService handles users' reports about abusive reviews
+type ReviewReportService struct {
+	Repo    repository.ReviewReportRepository
+	Reviews *ReviewService
+}
+
+// NewReviewReportService creates a new ReviewReportService
+func NewReviewReportService(repo repository.ReviewReportRepository, reviews *ReviewService) *ReviewReportService {
+	return &ReviewReportService{Repo: repo, Reviews: reviews}
+}
+
+// ReportReview files a user's report about a published review. Each user can report a
+// review once. A review is hidden automatically once it has enough open reports.
+func (s *ReviewReportService) ReportReview(reviewID, userID uint, req dto.ReportReviewRequestDTO) error {
+	review, err := s.Reviews.Repo.GetReviewByID(reviewID)
+	if err != nil || review.Status != models.ReviewApproved {
+		return utils.ErrNotFound
+	}
+	if review.UserID != nil && *review.UserID == userID {
+		return utils.ErrOwnReviewReport
+	}
+
+	reported, err := s.Repo.HasReported(reviewID, userID)
+	if err != nil {
+		return err
+	}
+	if reported {
+		return utils.ErrAlreadyReported
+	}
+
+	report := models.ReviewReport{
+		ReviewID: reviewID,
+		UserID:   userID,
+		Category: req.Category,
+		Details:  req.Details,
+	}
+	if err := s.Repo.CreateReport(&report); err != nil {
+		// A concurrent duplicate trips the unique index
+		if reported, _ := s.Repo.HasReported(reviewID, userID); reported {
+			return utils.ErrAlreadyReported
+		}
+		return err
+	}
+
+	threshold := config.Reviews.ReportHideThreshold
+	if threshold <= 0 {
+		return nil
+	}
+
+	count, err := s.Repo.CountOpenReports(reviewID)
+	if err != nil {
+		return err
+	}
+	if count >= int64(threshold) {
+		reason := fmt.Sprintf("Hidden automatically after %d reports, pending review by a moderator", count)
+		return s.Reviews.setStatus(&review, models.ReviewHidden, reason, nil)
+	}
+	return nil
+}
+
+// GetReportedReviews lists reviews with open reports, most reported first
+func (s *ReviewReportService) GetReportedReviews(page, pageSize int) (dto.ReportedReviewListResponseDTO, error) {
+	reviewIDs, total, err := s.Repo.GetReportedReviewIDs(page, pageSize)
+	if err != nil {
+		return dto.ReportedReviewListResponseDTO{}, err
+	}
+
+	groups := make([]dto.ReportedReviewDTO, 0, len(reviewIDs))
+	if len(reviewIDs) > 0 {
+		reports, err := s.Repo.GetOpenReports(reviewIDs)
+		if err != nil {
+			return dto.ReportedReviewListResponseDTO{}, err
+		}
+
+		byReview := make(map[uint]*dto.ReportedReviewDTO, len(reviewIDs))
+		for _, report := range reports {
+			group, ok := byReview[report.ReviewID]
+			if !ok {
+				group = &dto.ReportedReviewDTO{Review: toReviewResponse(report.Review), Categories: map[string]int{}}
+				byReview[report.ReviewID] = group
+			}
+			group.ReportCount++
+			group.Categories[report.Category]++
+			group.Reports = append(group.Reports, dto.ReviewReportResponseDTO{
+				ID:        report.ID,
+				ReviewID:  report.ReviewID,
+				UserID:    report.UserID,
+				Username:  report.User.Username,
+				Category:  report.Category,
+				Details:   report.Details,
+				CreatedAt: report.CreatedAt,
+			})
+		}
+
+		// Keep the order of the page
+		for _, id := range reviewIDs {
+			if group, ok := byReview[id]; ok {
+				groups = append(groups, *group)
+			}
+		}
+	}
+
+	return dto.ReportedReviewListResponseDTO{
+		Reviews:  groups,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	}, nil
+}
+
+// ResolveReports closes the open reports of a review. "hide" takes the review down with the
+// given reason; "dismiss" keeps it and restores it if it was hidden automatically.
+func (s *ReviewReportService) ResolveReports(reviewID, moderatorID uint, req dto.ResolveReportsRequestDTO) (dto.ReviewResponseDTO, error) {
+	if req.Action == "hide" && req.Reason == "" {
+		return dto.ReviewResponseDTO{}, utils.ErrBadRequest
+	}
+
+	review, err := s.Reviews.Repo.GetReviewByID(reviewID)
+	if err != nil {
+		return dto.ReviewResponseDTO{}, utils.ErrNotFound
+	}
+
+	resolution := models.ReportDismissed
+	if req.Action == "hide" {
+		resolution = models.ReportUpheld
+	}
+
+	resolved, err := s.Repo.ResolveReports(reviewID, moderatorID, resolution)
+	if err != nil {
+		return dto.ReviewResponseDTO{}, err
+	}
+	if resolved == 0 {
+		return dto.ReviewResponseDTO{}, utils.ErrNotFound
+	}
+
+	switch {
+	case req.Action == "hide":
+		err = s.Reviews.setStatus(&review, models.ReviewHidden, req.Reason, &moderatorID)
+	case review.Status == models.ReviewHidden && review.ModeratedByID == nil:
+		err = s.Reviews.setStatus(&review, models.ReviewApproved, "", &moderatorID)
+	}
+	if err != nil {
+		return dto.ReviewResponseDTO{}, err
+	}
+
+	return toReviewResponse(review), nil
+}
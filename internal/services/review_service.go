@@ -3,51 +3,94 @@ package services
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"mentalartsapi/config"
+	"mentalartsapi/internal/cache"
 	"mentalartsapi/internal/dto"
 	"mentalartsapi/internal/models"
 	"mentalartsapi/internal/repository"
 	"mentalartsapi/internal/utils"
+	"sort"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
 )
 
 // ReviewService manages book operations
 type ReviewService struct {
-	Repo  repository.ReviewRepository
-	Cache *redis.Client // Redis client
-	Ctx   context.Context
+	Repo    repository.ReviewRepository
+	Filters *ReviewFilterPipeline // Content filters run before reviews are saved
+	Cache   *redis.Client         // Redis client
+	Ctx     context.Context
+}
+
+// Orders accepted by GetReviews
+const (
+	ReviewSortNewest  = "newest"
+	ReviewSortHelpful = "helpful" // Most net helpful votes first
+)
+
+// ReviewActor is the user who reads or changes reviews
+type ReviewActor struct {
+	UserID    uint
+	Moderator bool // Holds reviews:moderate and may see and change any review
 }
 
 // NewReviewService creates a new ReviewService
-func NewReviewService(repo repository.ReviewRepository, cache *redis.Client, ctx context.Context) *ReviewService {
-	return &ReviewService{Repo: repo, Cache: cache, Ctx: ctx}
+func NewReviewService(repo repository.ReviewRepository, filters *ReviewFilterPipeline, cache *redis.Client, ctx context.Context) *ReviewService {
+	return &ReviewService{Repo: repo, Filters: filters, Cache: cache, Ctx: ctx}
+}
+
+// GetReviews retrieves the published reviews for a book in the given order and maps them to DTOs.
+// The actor's own review is included as well while it is not published.
+func (s *ReviewService) GetReviews(bookID uint, actor ReviewActor, order string) ([]dto.ReviewResponseDTO, error) {
+	reviewDTOs, err := s.getApprovedReviews(bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	own, err := s.Repo.GetUserReviewForBook(bookID, actor.UserID)
+	if err == nil && own.Status != models.ReviewApproved {
+		reviewDTOs = append(reviewDTOs, toReviewResponse(own))
+	} else if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	sortReviews(reviewDTOs, order)
+	return reviewDTOs, nil
+}
+
+// sortReviews orders reviews newest first, or by net helpful votes with ties broken by age.
+// Reviews posted at the same time are ordered by ID.
+func sortReviews(reviews []dto.ReviewResponseDTO, order string) {
+	sort.SliceStable(reviews, func(i, j int) bool {
+		if order == ReviewSortHelpful {
+			si := reviews[i].HelpfulCount - reviews[i].UnhelpfulCount
+			sj := reviews[j].HelpfulCount - reviews[j].UnhelpfulCount
+			if si != sj {
+				return si > sj
+			}
+		}
+		if !reviews[i].PostedAt.Equal(reviews[j].PostedAt) {
+			return reviews[i].PostedAt.After(reviews[j].PostedAt)
+		}
+		return reviews[i].ID > reviews[j].ID
+	})
 }
 
-// GetReviews retrieves all reviews for a book and maps them to DTOs
-func (s *ReviewService) GetReviews(bookID uint) ([]dto.ReviewResponseDTO, error) {
+// getApprovedReviews returns the published reviews of a book, cached for all readers
+func (s *ReviewService) getApprovedReviews(bookID uint) ([]dto.ReviewResponseDTO, error) {
 	// Check cache first
-	cacheKey := fmt.Sprintf("reviews_book:%d", bookID)
+	cacheKey := cache.BookReviewsKey(bookID)
 	cachedData, err := s.Cache.Get(s.Ctx, cacheKey).Result()
 	if err == redis.Nil { // Cache miss
 		// Fetch from DB
-		reviews, err := s.Repo.GetReviewsForBook(bookID)
+		reviews, err := s.Repo.GetReviewsForBook(bookID, models.ReviewApproved)
 		if err != nil {
 			return nil, err
 		}
 
-		var reviewDTOs []dto.ReviewResponseDTO
-		for _, review := range reviews {
-			reviewDTOs = append(reviewDTOs, dto.ReviewResponseDTO{
-				ID:         review.ID,
-				Rating:     review.Rating,
-				Comment:    review.Comment,
-				DatePosted: review.DatePosted,
-				BookID:     review.BookID,
-				BookTitle:  review.Book.Title,
-			})
-		}
+		reviewDTOs := toReviewResponses(reviews)
 
 		// Cache the data
 		cacheData, _ := json.Marshal(reviewDTOs)
@@ -67,17 +110,80 @@ func (s *ReviewService) GetReviews(bookID uint) ([]dto.ReviewResponseDTO, error)
 	}
 }
 
-// CreateReview creates a new review for a book from a DTO
-func (s *ReviewService) CreateReview(bookID uint, req dto.CreateReviewRequestDTO) (dto.ReviewResponseDTO, error) {
+// GetUserReviews retrieves the reviews written by a user, newest first.
+// Unpublished reviews are only listed for the user themselves and moderators.
+func (s *ReviewService) GetUserReviews(userID uint, actor ReviewActor) ([]dto.ReviewResponseDTO, error) {
+	status := models.ReviewApproved
+	if actor.Moderator || actor.UserID == userID {
+		status = ""
+	}
+
+	reviews, err := s.Repo.GetReviewsByUser(userID, status)
+	if err != nil {
+		return nil, err
+	}
+	return toReviewResponses(reviews), nil
+}
+
+// GetReview retrieves a single review by ID.
+// Unpublished reviews are only visible to their author and moderators.
+func (s *ReviewService) GetReview(id uint, actor ReviewActor) (dto.ReviewResponseDTO, error) {
+	review, err := s.Repo.GetReviewByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return dto.ReviewResponseDTO{}, utils.ErrNotFound
+		}
+		return dto.ReviewResponseDTO{}, err
+	}
+	if review.Status != models.ReviewApproved && !canManageReview(review, actor) {
+		return dto.ReviewResponseDTO{}, utils.ErrNotFound
+	}
+	return toReviewResponse(review), nil
+}
+
+// CreateReview creates a new review for a book from a DTO, written by the given user.
+// Each user can review a book once; a second review fails with a *utils.ReviewExistsError.
+// The content filters may reject the review, mask parts of it or hold it for moderation.
+// With pre-moderation the review waits for approval before it is published.
+func (s *ReviewService) CreateReview(bookID, userID uint, req dto.CreateReviewRequestDTO) (dto.ReviewResponseDTO, error) {
+	existing, err := s.Repo.GetUserReviewForBook(bookID, userID)
+	if err == nil {
+		return dto.ReviewResponseDTO{}, &utils.ReviewExistsError{ReviewID: existing.ID}
+	}
+	if err != gorm.ErrRecordNotFound {
+		return dto.ReviewResponseDTO{}, err
+	}
+
+	filtered, err := s.Filters.Run(ReviewContent{UserID: userID, Comment: req.Comment})
+	if err != nil {
+		return dto.ReviewResponseDTO{}, err
+	}
+
+	status := models.ReviewApproved
+	if config.Reviews.PreModeration {
+		status = models.ReviewPending
+	}
+
 	review := models.Review{
-		Rating:     req.Rating,
-		Comment:    req.Comment,
-		DatePosted: req.DatePosted,
-		BookID:     bookID,
+		Rating:   req.Rating,
+		Comment:  filtered.Comment,
+		PostedAt: time.Now(),
+		BookID:   bookID,
+		UserID:   &userID,
+		Status:   status,
+	}
+	if filtered.Flagged() {
+		review.Status = models.ReviewPending
+		review.ModerationReason = filtered.ModerationReason()
 	}
 
-	err := s.Repo.CreateReview(&review)
+	err = s.Repo.CreateReview(&review)
 	if err != nil {
+		filtered.Discard()
+		// A concurrent request may have won the race for the unique index
+		if existing, findErr := s.Repo.GetUserReviewForBook(bookID, userID); findErr == nil {
+			return dto.ReviewResponseDTO{}, &utils.ReviewExistsError{ReviewID: existing.ID}
+		}
 		return dto.ReviewResponseDTO{}, err
 	}
 
@@ -88,28 +194,56 @@ func (s *ReviewService) CreateReview(bookID uint, req dto.CreateReviewRequestDTO
 	}
 
 	// Invalidate cache when creating a new review
-	s.Cache.Del(s.Ctx, fmt.Sprintf("reviews_book:%d", bookID))
+	s.invalidateBook(bookID)
 
-	return dto.ReviewResponseDTO{
-		ID:         review.ID,
-		Rating:     review.Rating,
-		Comment:    review.Comment,
-		DatePosted: review.DatePosted,
-		BookID:     review.BookID,
-		BookTitle:  review.Book.Title,
-	}, nil
+	return toReviewResponse(review), nil
 }
 
-// UpdateReview updates an existing review using a DTO
-func (s *ReviewService) UpdateReview(id uint, req dto.CreateReviewRequestDTO) (dto.ReviewResponseDTO, error) {
+// SaveUserReview creates the user's review of a book or replaces it if there is one.
+// The boolean result reports whether a new review was created.
+func (s *ReviewService) SaveUserReview(bookID, userID uint, req dto.CreateReviewRequestDTO) (dto.ReviewResponseDTO, bool, error) {
+	existing, err := s.Repo.GetUserReviewForBook(bookID, userID)
+	if err == gorm.ErrRecordNotFound {
+		review, err := s.CreateReview(bookID, userID, req)
+		return review, err == nil, err
+	}
+	if err != nil {
+		return dto.ReviewResponseDTO{}, false, err
+	}
+
+	review, err := s.UpdateReview(existing.ID, ReviewActor{UserID: userID}, req)
+	return review, false, err
+}
+
+// UpdateReview updates an existing review using a DTO.
+// Only the author may change a review unless the caller is a moderator.
+// With pre-moderation an author's edit sends a published review back to the queue,
+// and an edited rejected review is always queued again.
+// Edits pass through the content filters like new reviews; moderators skip only the duplicate and rate checks.
+func (s *ReviewService) UpdateReview(id uint, actor ReviewActor, req dto.CreateReviewRequestDTO) (dto.ReviewResponseDTO, error) {
 	review, err := s.Repo.GetReviewByID(id)
+	if err != nil {
+		return dto.ReviewResponseDTO{}, utils.ErrNotFound
+	}
+	if !canManageReview(review, actor) {
+		return dto.ReviewResponseDTO{}, utils.ErrForbidden
+	}
+
+	filtered, err := s.Filters.Run(ReviewContent{UserID: actor.UserID, ReviewID: review.ID, Moderator: actor.Moderator, Comment: req.Comment})
 	if err != nil {
 		return dto.ReviewResponseDTO{}, err
 	}
 
+	now := time.Now()
 	review.Rating = req.Rating
-	review.Comment = req.Comment
-	review.DatePosted = req.DatePosted
+	review.Comment = filtered.Comment
+	review.EditedAt = &now
+	if filtered.Flagged() && review.Status != models.ReviewHidden {
+		review.Status = models.ReviewPending
+		review.ModerationReason = filtered.ModerationReason()
+	} else if !actor.Moderator && (review.Status == models.ReviewRejected || (config.Reviews.PreModeration && review.Status == models.ReviewApproved)) {
+		review.Status = models.ReviewPending
+	}
 
 	err = s.Repo.UpdateReview(&review)
 	if err != nil {
@@ -117,26 +251,23 @@ func (s *ReviewService) UpdateReview(id uint, req dto.CreateReviewRequestDTO) (d
 	}
 
 	// Invalidate cache when updating a review
-	s.Cache.Del(s.Ctx, fmt.Sprintf("reviews_book:%d", review.BookID))
+	s.invalidateBook(review.BookID)
 
-	return dto.ReviewResponseDTO{
-		ID:         review.ID,
-		Rating:     review.Rating,
-		Comment:    review.Comment,
-		DatePosted: review.DatePosted,
-		BookID:     review.BookID,
-		BookTitle:  review.Book.Title,
-	}, nil
+	return toReviewResponse(review), nil
 }
 
-// DeleteReview deletes a review by ID
-func (s *ReviewService) DeleteReview(id uint) error {
+// DeleteReview deletes a review by ID.
+// Only the author may delete a review unless the caller is a moderator.
+func (s *ReviewService) DeleteReview(id uint, actor ReviewActor) error {
 	// Yorumun var olup olmadığını kontrol et
 	review, err := s.Repo.GetReviewByID(id)
 	if err != nil {
 		// Eğer yorum bulunamazsa, NotFound hatası döndürüyoruz
 		return utils.ErrNotFound
 	}
+	if !canManageReview(review, actor) {
+		return utils.ErrForbidden
+	}
 
 	// Yorum silme işlemi
 	err = s.Repo.DeleteReview(id)
@@ -146,8 +277,148 @@ func (s *ReviewService) DeleteReview(id uint) error {
 	}
 
 	// Cache geçersiz kılma işlemi
-	s.Cache.Del(s.Ctx, fmt.Sprintf("reviews_book:%d", review.BookID))
-	s.Cache.Del(s.Ctx, fmt.Sprintf("reviews_book:%d", id)) // Yorumun kendi cache'ini de temizle
+	s.invalidateBook(review.BookID)
+
+	return nil
+}
+
+// VoteReview records whether a user found a published review helpful.
+// Voting again replaces the earlier vote. Authors cannot vote on their own reviews.
+func (s *ReviewService) VoteReview(id, userID uint, helpful bool) (dto.ReviewResponseDTO, error) {
+	review, err := s.Repo.GetReviewByID(id)
+	if err != nil || review.Status != models.ReviewApproved {
+		return dto.ReviewResponseDTO{}, utils.ErrNotFound
+	}
+	if review.UserID != nil && *review.UserID == userID {
+		return dto.ReviewResponseDTO{}, utils.ErrOwnReviewVote
+	}
+
+	if err := s.Repo.SetVote(id, userID, helpful); err != nil {
+		return dto.ReviewResponseDTO{}, err
+	}
+
+	// Reload to get the new totals
+	review, err = s.Repo.GetReviewByID(id)
+	if err != nil {
+		return dto.ReviewResponseDTO{}, err
+	}
+
+	s.Cache.Del(s.Ctx, cache.BookReviewsKey(review.BookID))
+
+	return toReviewResponse(review), nil
+}
 
+// RemoveVote withdraws a user's vote on a review
+func (s *ReviewService) RemoveVote(id, userID uint) error {
+	review, err := s.Repo.GetReviewByID(id)
+	if err != nil {
+		return utils.ErrNotFound
+	}
+
+	if err := s.Repo.DeleteVote(id, userID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return utils.ErrNotFound
+		}
+		return err
+	}
+
+	s.Cache.Del(s.Ctx, cache.BookReviewsKey(review.BookID))
 	return nil
 }
+
+// GetModerationQueue lists the reviews waiting for a moderator, oldest first
+func (s *ReviewService) GetModerationQueue(page, pageSize int) (dto.ReviewListResponseDTO, error) {
+	reviews, total, err := s.Repo.GetReviewsByStatus(models.ReviewPending, page, pageSize)
+	if err != nil {
+		return dto.ReviewListResponseDTO{}, err
+	}
+
+	return dto.ReviewListResponseDTO{
+		Reviews:  toReviewResponses(reviews),
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	}, nil
+}
+
+// ModerateReview records a moderator's decision on a review.
+// Rejecting or hiding a review requires a reason, which is shown to its author.
+func (s *ReviewService) ModerateReview(id, moderatorID uint, status, reason string) (dto.ReviewResponseDTO, error) {
+	if status != models.ReviewApproved && reason == "" {
+		return dto.ReviewResponseDTO{}, utils.ErrBadRequest
+	}
+
+	review, err := s.Repo.GetReviewByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return dto.ReviewResponseDTO{}, utils.ErrNotFound
+		}
+		return dto.ReviewResponseDTO{}, err
+	}
+
+	if err := s.setStatus(&review, status, reason, &moderatorID); err != nil {
+		return dto.ReviewResponseDTO{}, err
+	}
+
+	return toReviewResponse(review), nil
+}
+
+// setStatus stores a moderation decision. moderatorID is nil for automatic decisions.
+func (s *ReviewService) setStatus(review *models.Review, status, reason string, moderatorID *uint) error {
+	now := time.Now()
+	review.Status = status
+	review.ModerationReason = reason
+	review.ModeratedByID = moderatorID
+	review.ModeratedAt = &now
+
+	if err := s.Repo.UpdateReview(review); err != nil {
+		return err
+	}
+
+	s.invalidateBook(review.BookID)
+	return nil
+}
+
+// invalidateBook drops the cached reviews of a book, the cached book itself and the
+// cached book lists, whose rating aggregates and rating order change with its reviews
+func (s *ReviewService) invalidateBook(bookID uint) {
+	s.Cache.Del(s.Ctx, cache.BookReviewsKey(bookID), cache.BookKey(bookID))
+	s.Cache.Incr(s.Ctx, cache.BookListVersionKey)
+}
+
+// canManageReview reports whether a user may see an unpublished review, edit it or delete it.
+// Anonymous legacy reviews can only be managed by moderators.
+func canManageReview(review models.Review, actor ReviewActor) bool {
+	if actor.Moderator {
+		return true
+	}
+	return review.UserID != nil && *review.UserID == actor.UserID
+}
+
+// toReviewResponse maps a review with its book and user preloaded to a DTO
+func toReviewResponse(review models.Review) dto.ReviewResponseDTO {
+	return dto.ReviewResponseDTO{
+		ID:               review.ID,
+		Rating:           review.Rating,
+		Comment:          review.Comment,
+		PostedAt:         review.PostedAt,
+		EditedAt:         review.EditedAt,
+		BookID:           review.BookID,
+		BookTitle:        review.Book.Title,
+		UserID:           review.UserID,
+		Username:         review.User.Username,
+		Status:           review.Status,
+		ModerationReason: review.ModerationReason,
+		HelpfulCount:     review.HelpfulCount,
+		UnhelpfulCount:   review.UnhelpfulCount,
+	}
+}
+
+// toReviewResponses maps a list of reviews to DTOs
+func toReviewResponses(reviews []models.Review) []dto.ReviewResponseDTO {
+	reviewDTOs := make([]dto.ReviewResponseDTO, 0, len(reviews))
+	for _, review := range reviews {
+		reviewDTOs = append(reviewDTOs, toReviewResponse(review))
+	}
+	return reviewDTOs
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"mentalartsapi/internal/cache"
+	"mentalartsapi/internal/dto"
+	"mentalartsapi/internal/models"
+	"mentalartsapi/internal/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// maxDeviceLabelLength caps client supplied device names
+const maxDeviceLabelLength = 100
+
+// ClientInfo describes the device a login or token refresh came from
+type ClientInfo struct {
+	IPAddress   string
+	UserAgent   string
+	DeviceLabel string // Optional name chosen by the client, derived from the user agent otherwise
+}
+
+// ListSessions returns the active sessions of a user. The session of the
+// current request, if any, is flagged so clients can label it "this device".
+func (s *AuthService) ListSessions(userID, currentSessionID uint) ([]dto.SessionResponseDTO, error) {
+	sessions, err := s.SessionRepo.ListActiveForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionDTOs := make([]dto.SessionResponseDTO, 0, len(sessions))
+	for _, session := range sessions {
+		sessionDTOs = append(sessionDTOs, dto.SessionResponseDTO{
+			ID:          session.ID,
+			DeviceLabel: session.DeviceLabel,
+			UserAgent:   session.UserAgent,
+			IPAddress:   session.IPAddress,
+			CreatedAt:   session.CreatedAt,
+			LastSeenAt:  session.LastSeenAt,
+			ExpiresAt:   session.ExpiresAt,
+			Current:     session.ID == currentSessionID,
+		})
+	}
+	return sessionDTOs, nil
+}
+
+// RevokeSession signs a user out of one session. Its refresh tokens stop working
+// and access tokens issued for it are rejected right away.
+func (s *AuthService) RevokeSession(userID, sessionID uint) error {
+	session, err := s.SessionRepo.GetActiveSession(sessionID, userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return utils.ErrNotFound
+		}
+		return err
+	}
+	return s.revokeFamily(session.FamilyID)
+}
+
+// startSession records a new session for a refresh token family
+func (s *AuthService) startSession(userID uint, familyID string, client ClientInfo, expiresAt time.Time) (models.Session, error) {
+	label := strings.TrimSpace(client.DeviceLabel)
+	if label == "" {
+		label = utils.DescribeUserAgent(client.UserAgent)
+	}
+	if len(label) > maxDeviceLabelLength {
+		label = label[:maxDeviceLabelLength]
+	}
+
+	session := models.Session{
+		UserID:      userID,
+		FamilyID:    familyID,
+		DeviceLabel: label,
+		UserAgent:   client.UserAgent,
+		IPAddress:   client.IPAddress,
+		LastSeenAt:  time.Now(),
+		ExpiresAt:   expiresAt,
+	}
+	err := s.SessionRepo.CreateSession(&session)
+	return session, err
+}
+
+// revokeFamily revokes a refresh token family, ends its session and rejects the session's access tokens
+func (s *AuthService) revokeFamily(familyID string) error {
+	if err := s.TokenRepo.RevokeFamily(familyID); err != nil {
+		return err
+	}
+
+	session, err := s.SessionRepo.GetSessionByFamily(familyID)
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return cache.RevokeSessionTokens(session.ID)
+}
@@ -0,0 +1,363 @@
+package services
+
+import (
+	"mentalartsapi/config"
+	"mentalartsapi/internal/cache"
+	"mentalartsapi/internal/dto"
+	"mentalartsapi/internal/models"
+	"mentalartsapi/internal/repository"
+	"mentalartsapi/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// recoveryCodeCount is how many recovery codes are generated at a time
+const recoveryCodeCount = 10
+
+// TwoFactorService manages TOTP enrolment, recovery codes and the second step of the login
+type TwoFactorService struct {
+	Repo         repository.UserRepository
+	RecoveryRepo repository.RecoveryCodeRepository
+	AuthService  *AuthService
+}
+
+// NewTwoFactorService creates a new TwoFactorService
+func NewTwoFactorService(repo repository.UserRepository, recoveryRepo repository.RecoveryCodeRepository, authService *AuthService) *TwoFactorService {
+	return &TwoFactorService{Repo: repo, RecoveryRepo: recoveryRepo, AuthService: authService}
+}
+
+// GetStatus returns whether two-factor authentication is enabled and required for the user
+func (s *TwoFactorService) GetStatus(userID uint) (dto.TwoFactorStatusDTO, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return dto.TwoFactorStatusDTO{}, err
+	}
+
+	remaining, err := s.RecoveryRepo.CountUnused(user.ID)
+	if err != nil {
+		return dto.TwoFactorStatusDTO{}, err
+	}
+
+	return dto.TwoFactorStatusDTO{
+		Enabled:                user.TOTPEnabled,
+		Required:               twoFactorRequired(user),
+		RecoveryCodesRemaining: remaining,
+	}, nil
+}
+
+// BeginSetup generates a new TOTP secret. It only takes effect once confirmed with EnableTwoFactor.
+func (s *TwoFactorService) BeginSetup(userID uint) (dto.TwoFactorSetupResponseDTO, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return dto.TwoFactorSetupResponseDTO{}, err
+	}
+	if user.TOTPEnabled {
+		return dto.TwoFactorSetupResponseDTO{}, utils.ErrTwoFactorEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return dto.TwoFactorSetupResponseDTO{}, err
+	}
+
+	user.TOTPSecret = secret
+	if err := s.Repo.UpdateTwoFactor(&user); err != nil {
+		return dto.TwoFactorSetupResponseDTO{}, err
+	}
+
+	return setupResponse(user), nil
+}
+
+// EnableTwoFactor confirms the pending secret with a code from the app and returns the first recovery codes
+func (s *TwoFactorService) EnableTwoFactor(userID uint, code string) (dto.RecoveryCodesResponseDTO, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return dto.RecoveryCodesResponseDTO{}, err
+	}
+	if user.TOTPEnabled {
+		return dto.RecoveryCodesResponseDTO{}, utils.ErrTwoFactorEnabled
+	}
+	if user.TOTPSecret == "" {
+		return dto.RecoveryCodesResponseDTO{}, utils.ErrTwoFactorNotSetUp
+	}
+
+	codes, err := s.enable(&user, code)
+	if err != nil {
+		return dto.RecoveryCodesResponseDTO{}, err
+	}
+	return dto.RecoveryCodesResponseDTO{RecoveryCodes: codes}, nil
+}
+
+// DisableTwoFactor turns two-factor authentication off after checking a TOTP or recovery code
+func (s *TwoFactorService) DisableTwoFactor(userID uint, code string) error {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return utils.ErrTwoFactorNotSetUp
+	}
+	if twoFactorRequired(user) {
+		return utils.ErrTwoFactorRequired
+	}
+
+	if err := s.verifyCode(user, code); err != nil {
+		return err
+	}
+	return s.reset(&user)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after checking a TOTP code
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID uint, code string) (dto.RecoveryCodesResponseDTO, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return dto.RecoveryCodesResponseDTO{}, err
+	}
+	if !user.TOTPEnabled {
+		return dto.RecoveryCodesResponseDTO{}, utils.ErrTwoFactorNotSetUp
+	}
+
+	if err := s.verifyTOTP(user, code); err != nil {
+		return dto.RecoveryCodesResponseDTO{}, err
+	}
+
+	codes, err := s.newRecoveryCodes(user.ID)
+	if err != nil {
+		return dto.RecoveryCodesResponseDTO{}, err
+	}
+	return dto.RecoveryCodesResponseDTO{RecoveryCodes: codes}, nil
+}
+
+// ResetTwoFactor removes the second factor of a user who lost their authenticator and recovery codes
+func (s *TwoFactorService) ResetTwoFactor(userID uint) error {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return err
+	}
+	return s.reset(&user)
+}
+
+// StartChallenge decides whether a user who passed the first login step needs a second factor.
+// If so, it stores a short-lived challenge and returns it. Users who must use two-factor
+// authentication but have not enrolled get a secret to enrol with during the login. The secret
+// lives in the challenge until it is confirmed and is reused by further logins until it expires.
+func (s *TwoFactorService) StartChallenge(user models.User) (dto.TwoFactorChallengeResponseDTO, bool, error) {
+	if !user.TOTPEnabled && !twoFactorRequired(user) {
+		return dto.TwoFactorChallengeResponseDTO{}, false, nil
+	}
+
+	challenge := dto.TwoFactorChallengeResponseDTO{
+		TwoFactorRequired: true,
+		ExpiresIn:         int64(config.Auth.TwoFactorChallengeTTL.Seconds()),
+	}
+
+	pending := cache.LoginChallenge{UserID: user.ID}
+	if !user.TOTPEnabled {
+		candidate, err := utils.GenerateTOTPSecret()
+		if err != nil {
+			return dto.TwoFactorChallengeResponseDTO{}, false, err
+		}
+		pending.Secret, err = cache.PendingTOTPSecret(user.ID, candidate, config.Auth.TwoFactorChallengeTTL)
+		if err != nil {
+			return dto.TwoFactorChallengeResponseDTO{}, false, err
+		}
+
+		// Not saved: the user row only gets the secret once a code confirms it
+		user.TOTPSecret = pending.Secret
+		setup := setupResponse(user)
+		challenge.EnrollmentRequired = true
+		challenge.Secret = setup.Secret
+		challenge.ProvisioningURI = setup.ProvisioningURI
+	}
+
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return dto.TwoFactorChallengeResponseDTO{}, false, err
+	}
+	if err := cache.SaveLoginChallenge(utils.HashToken(token), pending, config.Auth.TwoFactorChallengeTTL); err != nil {
+		return dto.TwoFactorChallengeResponseDTO{}, false, err
+	}
+
+	challenge.ChallengeToken = token
+	return challenge, true, nil
+}
+
+// ChallengeUser returns the user a pending login challenge was issued for, so the caller
+// can apply the login attempt limits of that account before a code is checked
+func (s *TwoFactorService) ChallengeUser(challengeToken string) (models.User, error) {
+	challenge, ok, err := cache.GetLoginChallenge(utils.HashToken(challengeToken))
+	if err != nil {
+		return models.User{}, err
+	}
+	if !ok {
+		return models.User{}, utils.ErrInvalidChallenge
+	}
+
+	user, err := s.getUser(challenge.UserID)
+	if err == utils.ErrNotFound {
+		return models.User{}, utils.ErrInvalidChallenge
+	}
+	return user, err
+}
+
+// CompleteChallenge checks the second factor of a login challenge issued for user and issues a token pair.
+// A challenge is dropped after too many wrong codes; the caller counts wrong codes towards the
+// account and IP lockout as well, so fresh challenges cannot be used to keep guessing.
+func (s *TwoFactorService) CompleteChallenge(user models.User, req dto.TwoFactorLoginRequestDTO, client ClientInfo) (dto.TwoFactorLoginResponseDTO, error) {
+	tokenHash := utils.HashToken(req.ChallengeToken)
+
+	challenge, ok, err := cache.GetLoginChallenge(tokenHash)
+	if err != nil {
+		return dto.TwoFactorLoginResponseDTO{}, err
+	}
+	if !ok || challenge.UserID != user.ID {
+		return dto.TwoFactorLoginResponseDTO{}, utils.ErrInvalidChallenge
+	}
+
+	attempts, err := cache.CountChallengeAttempt(tokenHash, config.Auth.TwoFactorChallengeTTL)
+	if err != nil {
+		return dto.TwoFactorLoginResponseDTO{}, err
+	}
+	if attempts > int64(config.Auth.TwoFactorMaxAttempts) {
+		cache.DeleteLoginChallenge(tokenHash)
+		return dto.TwoFactorLoginResponseDTO{}, utils.ErrInvalidChallenge
+	}
+
+	if !user.IsActive {
+		return dto.TwoFactorLoginResponseDTO{}, utils.ErrAccountDisabled
+	}
+
+	var response dto.TwoFactorLoginResponseDTO
+	if user.TOTPEnabled {
+		err = s.verifyCode(user, req.Code)
+	} else if challenge.Secret == "" {
+		// Two-factor authentication was reset after the challenge was issued
+		return dto.TwoFactorLoginResponseDTO{}, utils.ErrInvalidChallenge
+	} else {
+		// Mandatory enrolment: the first valid code confirms the secret handed out with the challenge
+		user.TOTPSecret = challenge.Secret
+		response.RecoveryCodes, err = s.enable(&user, req.Code)
+	}
+	if err != nil {
+		return dto.TwoFactorLoginResponseDTO{}, err
+	}
+	if challenge.Secret != "" {
+		if err := cache.DeletePendingTOTPSecret(user.ID); err != nil {
+			return dto.TwoFactorLoginResponseDTO{}, err
+		}
+	}
+
+	if err := cache.DeleteLoginChallenge(tokenHash); err != nil {
+		return dto.TwoFactorLoginResponseDTO{}, err
+	}
+
+	response.TokenResponseDTO, err = s.AuthService.IssueTokens(user, client)
+	return response, err
+}
+
+// enable checks a code against the pending secret, turns two-factor authentication on and returns new recovery codes
+func (s *TwoFactorService) enable(user *models.User, code string) ([]string, error) {
+	if user.TOTPSecret == "" {
+		return nil, utils.ErrTwoFactorNotSetUp
+	}
+	if err := s.verifyTOTP(*user, code); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user.TOTPEnabled = true
+	user.TOTPEnabledAt = &now
+	if err := s.Repo.UpdateTwoFactor(user); err != nil {
+		return nil, err
+	}
+	return s.newRecoveryCodes(user.ID)
+}
+
+// reset turns two-factor authentication off and removes the secret and recovery codes
+func (s *TwoFactorService) reset(user *models.User) error {
+	user.TOTPEnabled = false
+	user.TOTPEnabledAt = nil
+	user.TOTPSecret = ""
+	if err := s.Repo.UpdateTwoFactor(user); err != nil {
+		return err
+	}
+	return s.RecoveryRepo.DeleteForUser(user.ID)
+}
+
+// verifyCode accepts either a TOTP code or an unused recovery code
+func (s *TwoFactorService) verifyCode(user models.User, code string) error {
+	if err := s.verifyTOTP(user, code); err != utils.ErrInvalidTwoFactor {
+		return err
+	}
+
+	used, err := s.RecoveryRepo.UseCode(user.ID, utils.HashToken(utils.NormalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return utils.ErrInvalidTwoFactor
+	}
+	return nil
+}
+
+// verifyTOTP checks a TOTP code and makes sure it has not been used before
+func (s *TwoFactorService) verifyTOTP(user models.User, code string) error {
+	step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return utils.ErrInvalidTwoFactor
+	}
+
+	fresh, err := cache.MarkTOTPStepUsed(user.ID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return utils.ErrInvalidTwoFactor
+	}
+	return nil
+}
+
+// newRecoveryCodes replaces the user's recovery codes and returns the new ones in plain text
+func (s *TwoFactorService) newRecoveryCodes(userID uint) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]models.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := utils.GenerateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, models.RecoveryCode{
+			UserID:   userID,
+			CodeHash: utils.HashToken(utils.NormalizeRecoveryCode(code)),
+		})
+	}
+
+	if err := s.RecoveryRepo.ReplaceCodes(userID, records); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// getUser loads a user and maps a missing record to utils.ErrNotFound
+func (s *TwoFactorService) getUser(userID uint) (models.User, error) {
+	user, err := s.Repo.GetUserByID(userID)
+	if err == gorm.ErrRecordNotFound {
+		return models.User{}, utils.ErrNotFound
+	}
+	return user, err
+}
+
+// twoFactorRequired reports whether the user's role must use two-factor authentication
+func twoFactorRequired(user models.User) bool {
+	return config.Auth.RequireTwoFactorAdmins && user.Role.Name == utils.RoleAdmin
+}
+
+// setupResponse returns the pending secret of a user together with its provisioning URI
+func setupResponse(user models.User) dto.TwoFactorSetupResponseDTO {
+	return dto.TwoFactorSetupResponseDTO{
+		Secret:          user.TOTPSecret,
+		ProvisioningURI: utils.TOTPProvisioningURI(config.Auth.TOTPIssuer, user.Email, user.TOTPSecret),
+	}
+}
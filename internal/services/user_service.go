@@ -0,0 +1,180 @@
+package services
+
+import (
+	"mentalartsapi/internal/cache"
+	"mentalartsapi/internal/dto"
+	"mentalartsapi/internal/models"
+	"mentalartsapi/internal/repository"
+	"mentalartsapi/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserService manages user accounts on behalf of admins
+type UserService struct {
+	Repo        repository.UserRepository
+	RoleRepo    repository.RoleRepository
+	AuthService *AuthService
+}
+
+// NewUserService creates a new UserService
+func NewUserService(repo repository.UserRepository, roleRepo repository.RoleRepository, authService *AuthService) *UserService {
+	return &UserService{Repo: repo, RoleRepo: roleRepo, AuthService: authService}
+}
+
+// ListUsers returns one page of users matching the search term
+func (s *UserService) ListUsers(page, pageSize int, search string) (dto.UserListResponseDTO, error) {
+	users, total, err := s.Repo.ListUsers(page, pageSize, search)
+	if err != nil {
+		return dto.UserListResponseDTO{}, err
+	}
+
+	userDTOs := make([]dto.UserResponseDTO, 0, len(users))
+	for _, user := range users {
+		userDTOs = append(userDTOs, toUserResponseDTO(user))
+	}
+
+	return dto.UserListResponseDTO{
+		Users:    userDTOs,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	}, nil
+}
+
+// GetUser returns a single user
+func (s *UserService) GetUser(id uint) (dto.UserResponseDTO, error) {
+	user, err := s.getUser(id)
+	if err != nil {
+		return dto.UserResponseDTO{}, err
+	}
+	return toUserResponseDTO(user), nil
+}
+
+// UpdateUserRole assigns a new role and revokes the user's tokens so the new permissions apply at once
+func (s *UserService) UpdateUserRole(actorID, id uint, roleName string) (dto.UserResponseDTO, error) {
+	if actorID == id {
+		return dto.UserResponseDTO{}, utils.ErrCannotModifySelf
+	}
+
+	user, err := s.getUser(id)
+	if err != nil {
+		return dto.UserResponseDTO{}, err
+	}
+
+	role, err := s.RoleRepo.GetRoleByName(roleName)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return dto.UserResponseDTO{}, utils.ErrBadRequest
+		}
+		return dto.UserResponseDTO{}, err
+	}
+
+	user.RoleID = &role.ID
+	user.Role = role
+	if err := s.Repo.UpdateUser(&user); err != nil {
+		return dto.UserResponseDTO{}, err
+	}
+
+	if err := s.AuthService.LogoutAll(user.ID); err != nil {
+		return dto.UserResponseDTO{}, err
+	}
+
+	return toUserResponseDTO(user), nil
+}
+
+// DeactivateUser blocks a user from logging in and rejects all of their tokens
+func (s *UserService) DeactivateUser(actorID, id uint) (dto.UserResponseDTO, error) {
+	if actorID == id {
+		return dto.UserResponseDTO{}, utils.ErrCannotModifySelf
+	}
+
+	user, err := s.getUser(id)
+	if err != nil {
+		return dto.UserResponseDTO{}, err
+	}
+
+	if user.IsActive {
+		now := time.Now()
+		user.IsActive = false
+		user.DeactivatedAt = &now
+		if err := s.Repo.UpdateUser(&user); err != nil {
+			return dto.UserResponseDTO{}, err
+		}
+	}
+
+	if err := cache.SetUserDisabled(user.ID, true); err != nil {
+		return dto.UserResponseDTO{}, err
+	}
+	if err := s.AuthService.LogoutAll(user.ID); err != nil {
+		return dto.UserResponseDTO{}, err
+	}
+
+	return toUserResponseDTO(user), nil
+}
+
+// ReactivateUser lets a deactivated user log in again
+func (s *UserService) ReactivateUser(id uint) (dto.UserResponseDTO, error) {
+	user, err := s.getUser(id)
+	if err != nil {
+		return dto.UserResponseDTO{}, err
+	}
+
+	if !user.IsActive {
+		user.IsActive = true
+		user.DeactivatedAt = nil
+		if err := s.Repo.UpdateUser(&user); err != nil {
+			return dto.UserResponseDTO{}, err
+		}
+	}
+
+	if err := cache.SetUserDisabled(user.ID, false); err != nil {
+		return dto.UserResponseDTO{}, err
+	}
+
+	return toUserResponseDTO(user), nil
+}
+
+// DeleteUser removes a user and revokes all of their tokens
+func (s *UserService) DeleteUser(actorID, id uint) error {
+	if actorID == id {
+		return utils.ErrCannotModifySelf
+	}
+
+	if _, err := s.getUser(id); err != nil {
+		return err
+	}
+
+	if err := s.Repo.DeleteUser(id); err != nil {
+		return err
+	}
+	return s.AuthService.LogoutAll(id)
+}
+
+// getUser loads a user and maps a missing record to utils.ErrNotFound
+func (s *UserService) getUser(id uint) (models.User, error) {
+	user, err := s.Repo.GetUserByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.User{}, utils.ErrNotFound
+		}
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// toUserResponseDTO maps a user to its public representation
+func toUserResponseDTO(user models.User) dto.UserResponseDTO {
+	return dto.UserResponseDTO{
+		ID:               user.ID,
+		Username:         user.Username,
+		Email:            user.Email,
+		Role:             user.Role.Name,
+		EmailVerified:    user.EmailVerified,
+		TwoFactorEnabled: user.TOTPEnabled,
+		IsActive:         user.IsActive,
+		DeactivatedAt:    user.DeactivatedAt,
+		CreatedAt:        user.CreatedAt,
+	}
+}
@@ -0,0 +1,251 @@
+// Package testutil holds helpers shared by the tests of several packages.
+package testutil
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mentalartsapi/config"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// FakeRedis is a minimal in-memory Redis server that speaks enough of the RESP protocol
+// for the commands the application sends. Keys expire like they would in Redis.
+type FakeRedis struct {
+	mu      sync.Mutex
+	values  map[string]string
+	expires map[string]time.Time
+}
+
+// UseFakeRedis points config.Redis at a fresh fake server for the duration of the test
+func UseFakeRedis(t *testing.T) *FakeRedis {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := &FakeRedis{values: map[string]string{}, expires: map[string]time.Time{}}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+
+	previous := config.Redis
+	config.Redis = redis.NewClient(&redis.Options{Addr: listener.Addr().String()})
+	t.Cleanup(func() {
+		config.Redis.Close()
+		config.Redis = previous
+		listener.Close()
+	})
+	return server
+}
+
+// Value returns the value stored under key
+func (f *FakeRedis) Value(key string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.expire(key)
+	value, ok := f.values[key]
+	return value, ok
+}
+
+// serve answers the commands sent over one connection
+func (f *FakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		if _, err := io.WriteString(conn, f.execute(args)); err != nil {
+			return
+		}
+	}
+}
+
+// execute runs one command and returns its RESP encoded reply
+func (f *FakeRedis) execute(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	command := strings.ToUpper(args[0])
+	if len(args) > 1 {
+		f.expire(args[1])
+	}
+
+	switch command {
+	case "PING":
+		return "+PONG\r\n"
+	case "SET", "SETNX":
+		return f.set(command, args[1:])
+	case "GET", "GETDEL":
+		value, ok := f.values[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		if command == "GETDEL" {
+			f.delete(args[1])
+		}
+		return bulk(value)
+	case "DEL", "EXISTS":
+		found := 0
+		for _, key := range args[1:] {
+			f.expire(key)
+			if _, ok := f.values[key]; ok {
+				found++
+				if command == "DEL" {
+					f.delete(key)
+				}
+			}
+		}
+		return integer(int64(found))
+	case "INCR", "DECR":
+		value, err := strconv.ParseInt(f.valueOr(args[1], "0"), 10, 64)
+		if err != nil {
+			return "-ERR value is not an integer or out of range\r\n"
+		}
+		if command == "INCR" {
+			value++
+		} else {
+			value--
+		}
+		f.values[args[1]] = strconv.FormatInt(value, 10)
+		return integer(value)
+	case "EXPIRE", "PEXPIRE":
+		if _, ok := f.values[args[1]]; !ok {
+			return integer(0)
+		}
+		amount, _ := strconv.ParseInt(args[2], 10, 64)
+		unit := time.Second
+		if command == "PEXPIRE" {
+			unit = time.Millisecond
+		}
+		f.expires[args[1]] = time.Now().Add(time.Duration(amount) * unit)
+		return integer(1)
+	case "TTL", "PTTL":
+		if _, ok := f.values[args[1]]; !ok {
+			return integer(-2)
+		}
+		deadline, ok := f.expires[args[1]]
+		if !ok {
+			return integer(-1)
+		}
+		if command == "TTL" {
+			return integer(int64(time.Until(deadline).Round(time.Second) / time.Second))
+		}
+		return integer(time.Until(deadline).Milliseconds())
+	}
+	return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+}
+
+// set handles SET with its EX, PX and NX options, and SETNX
+func (f *FakeRedis) set(command string, args []string) string {
+	key, value := args[0], args[1]
+	onlyNew := command == "SETNX"
+	var ttl time.Duration
+	for i := 2; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "NX":
+			onlyNew = true
+		case "EX", "PX":
+			amount, _ := strconv.ParseInt(args[i+1], 10, 64)
+			ttl = time.Duration(amount) * time.Second
+			if strings.EqualFold(args[i], "PX") {
+				ttl = time.Duration(amount) * time.Millisecond
+			}
+			i++
+		}
+	}
+
+	if _, exists := f.values[key]; exists && onlyNew {
+		if command == "SETNX" {
+			return integer(0)
+		}
+		return "$-1\r\n"
+	}
+	f.values[key] = value
+	delete(f.expires, key)
+	if ttl > 0 {
+		f.expires[key] = time.Now().Add(ttl)
+	}
+	if command == "SETNX" {
+		return integer(1)
+	}
+	return "+OK\r\n"
+}
+
+// expire drops key if its time is up
+func (f *FakeRedis) expire(key string) {
+	if deadline, ok := f.expires[key]; ok && !time.Now().Before(deadline) {
+		f.delete(key)
+	}
+}
+
+func (f *FakeRedis) delete(key string) {
+	delete(f.values, key)
+	delete(f.expires, key)
+}
+
+func (f *FakeRedis) valueOr(key, fallback string) string {
+	if value, ok := f.values[key]; ok {
+		return value
+	}
+	return fallback
+}
+
+func bulk(value string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+}
+
+func integer(value int64) string {
+	return fmt.Sprintf(":%d\r\n", value)
+}
+
+// readCommand reads one command, sent as an array of bulk strings
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("unexpected line %q", line)
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil || count < 1 {
+		return nil, fmt.Errorf("invalid array length in %q", line)
+	}
+
+	args := make([]string, count)
+	for i := range args {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(header, "$")))
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
@@ -0,0 +1,156 @@
+package utils
+
+import (
+	_ "embed"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+//go:embed banned_words.txt
+var bundledBannedWords string
+
+// Actions a review content filter can take when it finds something
+const (
+	FilterOff    = "off"    // The filter is disabled
+	FilterMask   = "mask"   // Replace the offending text and publish the rest
+	FilterFlag   = "flag"   // Hold the review for a moderator
+	FilterReject = "reject" // Refuse to save the review
+)
+
+// ContentRejectedError explains why a content filter refused a review or a reply
+type ContentRejectedError struct {
+	Subject string // "review" or "reply"
+	Reason  string
+}
+
+func (e *ContentRejectedError) Error() string {
+	subject := e.Subject
+	if subject == "" {
+		subject = "review"
+	}
+	return subject + " rejected: " + e.Reason
+}
+
+// WordList finds and masks blocked words
+type WordList struct {
+	words map[string]struct{}
+}
+
+// NewWordList creates a word list from the bundled English and Turkish words and the given extra ones
+func NewWordList(extra []string) *WordList {
+	w := &WordList{words: map[string]struct{}{}}
+	for _, list := range [][]string{strings.Split(bundledBannedWords, "\n"), extra} {
+		for _, line := range list {
+			line = strings.TrimSpace(line)
+			if line != "" && !strings.HasPrefix(line, "#") {
+				w.words[foldWord(line)] = struct{}{}
+			}
+		}
+	}
+	return w
+}
+
+// Find returns the blocked words in the text, each once, in the order they appear
+func (w *WordList) Find(text string) []string {
+	var found []string
+	seen := map[string]bool{}
+	w.scan(text, func(word string, _, _ int) {
+		if !seen[word] {
+			seen[word] = true
+			found = append(found, word)
+		}
+	})
+	return found
+}
+
+// Mask replaces every letter of each blocked word with an asterisk
+func (w *WordList) Mask(text string) string {
+	var b strings.Builder
+	last := 0
+	w.scan(text, func(word string, start, end int) {
+		b.WriteString(text[last:start])
+		b.WriteString(strings.Repeat("*", len([]rune(text[start:end]))))
+		last = end
+	})
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// foldWord lowercases a word and treats the Turkish dotless ı like i, since
+// "AMINA" lowercases to "amina" outside of Turkish case rules
+func foldWord(word string) string {
+	return strings.ReplaceAll(strings.ToLower(word), "ı", "i")
+}
+
+// scan calls match with the folded word and its byte offsets for each blocked word in the text.
+// Words are runs of letters and digits, so "class" does not match "ass".
+func (w *WordList) scan(text string, match func(word string, start, end int)) {
+	start := -1
+	for i, r := range text + " " {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			word := foldWord(text[start:i])
+			if _, ok := w.words[word]; ok {
+				match(word, start, i)
+			}
+			start = -1
+		}
+	}
+}
+
+var (
+	linkPattern  = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+|\b[a-z0-9-]+(?:\.[a-z0-9-]+)*\.(?:com|net|org|info|biz|io|co|me|ly|xyz|tr)\b(?:/\S*)?`)
+	phonePattern = regexp.MustCompile(`\+?\(?\b\d(?:[ ().-]{0,2}\d){9,13}\b`)
+)
+
+// FindContact returns descriptions of the links and phone numbers in the text
+func FindContact(text string) []string {
+	var found []string
+	if linkPattern.MatchString(text) {
+		found = append(found, "a link")
+	}
+	for _, match := range phonePattern.FindAllString(text, -1) {
+		if !isISBN(match) {
+			found = append(found, "a phone number")
+			break
+		}
+	}
+	return found
+}
+
+// MaskContact replaces links and phone numbers in the text with placeholders
+func MaskContact(text string) string {
+	text = linkPattern.ReplaceAllString(text, "[link removed]")
+	return phonePattern.ReplaceAllStringFunc(text, func(match string) string {
+		if isISBN(match) {
+			return match
+		}
+		return "[phone number removed]"
+	})
+}
+
+// isISBN reports whether a number that looks like a phone number is an ISBN-13,
+// which reviews quote often enough to be let through
+func isISBN(number string) bool {
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, number)
+	return len(digits) == 13 && (strings.HasPrefix(digits, "978") || strings.HasPrefix(digits, "979"))
+}
+
+// NormalizeText lowercases the text and reduces it to its words, so that
+// copies differing only in case, punctuation or spacing compare equal
+func NormalizeText(text string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
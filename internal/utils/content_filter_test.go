@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWordListMask(t *testing.T) {
+	words := NewWordList([]string{"spoiler", " Kötü ", "# not a word"})
+
+	// Each pair is the text and what Mask should turn it into
+	for _, pair := range [][2]string{
+		{"A lovely, well paced story.", "A lovely, well paced story."},
+		{"What a shit ending.", "What a **** ending."},
+		{"SHIT, what an ending", "****, what an ending"},
+		{"A classic assessment of Scunthorpe.", "A classic assessment of Scunthorpe."},
+		{"Huge spoiler ahead", "Huge ******* ahead"},
+		{"Çok KÖTÜ bir son", "Çok **** bir son"},
+		{"OROSPU gibi yazılmış", "****** gibi yazılmış"},
+		{"shit, shit and spoiler", "****, **** and *******"},
+		{"# not a word", "# not a word"},
+	} {
+		if got := words.Mask(pair[0]); got != pair[1] {
+			t.Errorf("Mask(%q) = %q, want %q", pair[0], got, pair[1])
+		}
+	}
+}
+
+func TestWordListFind(t *testing.T) {
+	words := NewWordList([]string{"spoiler"})
+
+	// Every word is reported once, in the order it first appears
+	got := words.Find("Spoiler: shit happens, then another spoiler")
+	if want := []string{"spoiler", "shit"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Find = %q, want %q", got, want)
+	}
+	if got := words.Find("Nothing to see"); got != nil {
+		t.Errorf("Find on clean text = %q, want nil", got)
+	}
+}
+
+func TestFindContact(t *testing.T) {
+	flagged := map[string][]string{
+		"Cheaper at https://example.com/deal":   {"a link"},
+		"Order it from bookshop.com.tr today":   {"a link"},
+		"see www.example.org":                   {"a link"},
+		"Call me on +90 (532) 123 45 67":        {"a phone number"},
+		"Text 555-123-4567 for a copy":          {"a phone number"},
+		"Visit example.net or call 05321234567": {"a link", "a phone number"},
+	}
+	for text, want := range flagged {
+		if got := FindContact(text); !reflect.DeepEqual(got, want) {
+			t.Errorf("FindContact(%q) = %q, want %q", text, got, want)
+		}
+	}
+
+	// Numbers reviews quote all the time must not be mistaken for contact details
+	for _, text := range []string{
+		"Read it in two evenings, 10/10.",
+		"ISBN 978-0-14-044913-6 is the edition I read",
+		"Published in 1869, 1225 pages",
+	} {
+		if got := FindContact(text); got != nil {
+			t.Errorf("FindContact(%q) = %q, want nil", text, got)
+		}
+	}
+}
+
+func TestMaskContact(t *testing.T) {
+	got := MaskContact("Buy it at https://example.com or call 555-123-4567, ISBN 978-0-14-044913-6")
+	want := "Buy it at [link removed] or call [phone number removed], ISBN 978-0-14-044913-6"
+	if got != want {
+		t.Errorf("MaskContact = %q, want %q", got, want)
+	}
+}
+
+func TestNormalizeText(t *testing.T) {
+	a := NormalizeText("Great book!!  Loved   it.")
+	b := NormalizeText("great book, loved it")
+	if a != b || a != "great book loved it" {
+		t.Errorf("NormalizeText gave %q and %q, want both %q", a, b, "great book loved it")
+	}
+}
+
+func TestContentRejectedError(t *testing.T) {
+	if got := (&ContentRejectedError{Reason: "it contains a link"}).Error(); got != "review rejected: it contains a link" {
+		t.Errorf("Error() = %q", got)
+	}
+	if got := (&ContentRejectedError{Subject: "reply", Reason: "it is a duplicate"}).Error(); got != "reply rejected: it is a duplicate" {
+		t.Errorf("Error() = %q", got)
+	}
+}
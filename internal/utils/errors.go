@@ -1,10 +1,67 @@
 package utils
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var (
-	ErrInvalidID  = errors.New("invalid ID format")
-	ErrNotFound   = errors.New("record not found")
-	ErrBadRequest = errors.New("bad request data")
-	ErrInternal   = errors.New("internal server error")
+	ErrInvalidID           = errors.New("invalid ID format")
+	ErrNotFound            = errors.New("record not found")
+	ErrBadRequest          = errors.New("bad request data")
+	ErrInternal            = errors.New("internal server error")
+	ErrForbidden           = errors.New("you are not allowed to change this resource")
+	ErrReviewExists        = errors.New("you have already reviewed this book")
+	ErrOwnReviewVote       = errors.New("you cannot vote on your own review")
+	ErrOwnReviewReport     = errors.New("you cannot report your own review")
+	ErrAlreadyReported     = errors.New("you have already reported this review")
+	ErrReplyTooDeep        = errors.New("replies cannot be nested this deeply")
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrInvalidActionToken  = errors.New("invalid or expired token")
+	ErrEmailNotVerified    = errors.New("email address is not verified")
+	ErrIncorrectPassword   = errors.New("current password is incorrect")
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrAccountLocked       = errors.New("account is temporarily locked after too many failed login attempts")
+	ErrTooManyAttempts     = errors.New("too many failed login attempts, please try again later")
+	ErrAccountDisabled     = errors.New("account has been deactivated")
+	ErrCannotModifySelf    = errors.New("admins cannot change their own role or status")
+	ErrEmailTaken          = errors.New("email address is already in use")
+	ErrUsernameTaken       = errors.New("username is already taken")
+	ErrInvalidAPIKey       = errors.New("invalid, expired or revoked API key")
+	ErrScopeNotAllowed     = errors.New("API key scopes must be permissions you hold")
+	ErrOIDCDisabled        = errors.New("OpenID Connect login is not configured")
+	ErrInvalidOIDCState    = errors.New("invalid or expired login state")
+	ErrOIDCLoginFailed     = errors.New("login with the identity provider failed")
+	ErrInvalidTwoFactor    = errors.New("invalid two-factor code")
+	ErrInvalidChallenge    = errors.New("invalid or expired login challenge")
+	ErrTwoFactorEnabled    = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotSetUp   = errors.New("two-factor authentication has not been set up")
+	ErrTwoFactorRequired   = errors.New("two-factor authentication is mandatory for your role")
 )
+
+// ReviewExistsError points to the review a user already wrote for a book
+type ReviewExistsError struct {
+	ReviewID uint
+}
+
+func (e *ReviewExistsError) Error() string {
+	return ErrReviewExists.Error()
+}
+
+func (e *ReviewExistsError) Unwrap() error {
+	return ErrReviewExists
+}
+
+// RetryAfterError tells the client how long to wait before trying again
+type RetryAfterError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
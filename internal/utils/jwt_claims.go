@@ -2,10 +2,43 @@ package utils
 
 import "github.com/dgrijalva/jwt-go"
 
-// JWTClaims represents the JWT token claims
+// JWTClaims represents the JWT token claims.
+// Every access token carries a unique ID in the standard "jti" claim (StandardClaims.Id)
+// so that it can be revoked individually before it expires.
 type JWTClaims struct {
-	ID    uint   `json:"id"`
-	Email string `json:"email"`
-	Role  string `json:"role"`
+	ID          uint     `json:"id"`
+	Email       string   `json:"email"`
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+	SessionID   uint     `json:"sid,omitempty"`    // Session the token was issued for, 0 for API keys
+	IssuedAtMs  int64    `json:"iat_ms,omitempty"` // Issue time in milliseconds, "iat" only has second precision
+	jwt.StandardClaims
+}
+
+// HasPermission reports whether the token grants the given permission
+func (c *JWTClaims) HasPermission(permission string) bool {
+	for _, p := range c.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// IssuedAtMillis returns when the token was issued in unix milliseconds,
+// falling back to the second-precision "iat" claim for older tokens.
+func (c *JWTClaims) IssuedAtMillis() int64 {
+	if c.IssuedAtMs != 0 {
+		return c.IssuedAtMs
+	}
+	return c.IssuedAt * 1000
+}
+
+// ActionTokenClaims are the claims of signed, single-use tokens that are sent by
+// email, such as email verification links. The audience names the action so an
+// action token can never be used as an access token or for another action.
+type ActionTokenClaims struct {
+	UserID uint   `json:"uid"`
+	Email  string `json:"email"`
 	jwt.StandardClaims
 }
@@ -0,0 +1,217 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+var (
+	ErrUnknownSigningKey = errors.New("unknown signing key")
+	ErrInvalidIssuer     = errors.New("invalid token issuer")
+	ErrInvalidAudience   = errors.New("invalid token audience")
+)
+
+// SigningKey is a single JWT key identified by its kid.
+// PrivateKey is nil for keys that are only kept to verify older tokens.
+type SigningKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey interface{}
+	PublicKey  interface{}
+}
+
+// KeySet holds every key that may verify a token and the one used to sign new tokens
+type KeySet struct {
+	Keys      map[string]*SigningKey
+	ActiveKID string
+	Issuer    string
+	Audience  string
+}
+
+// JWK is the JSON Web Key representation of a public key
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKSet is the document served at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Sign stamps the issuer and audience on the claims and signs them with the active key
+func (ks *KeySet) Sign(claims *JWTClaims) (string, error) {
+	claims.Issuer = ks.Issuer
+	claims.Audience = ks.Audience
+	return ks.SignClaims(claims)
+}
+
+// Parse verifies the signature, expiry, issuer and audience of a token and fills claims
+func (ks *KeySet) Parse(tokenString string, claims *JWTClaims) error {
+	if err := ks.ParseClaims(tokenString, claims); err != nil {
+		return err
+	}
+
+	if !claims.VerifyIssuer(ks.Issuer, true) {
+		return ErrInvalidIssuer
+	}
+	if !claims.VerifyAudience(ks.Audience, true) {
+		return ErrInvalidAudience
+	}
+	return nil
+}
+
+// SignClaims signs arbitrary claims with the active key.
+// Callers are responsible for setting an audience that sets the token apart from access tokens.
+func (ks *KeySet) SignClaims(claims jwt.Claims) (string, error) {
+	key, ok := ks.Keys[ks.ActiveKID]
+	if !ok || key.PrivateKey == nil {
+		return "", ErrUnknownSigningKey
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.PrivateKey)
+}
+
+// ParseClaims verifies the signature and time based claims of a token and fills claims
+func (ks *KeySet) ParseClaims(tokenString string, claims jwt.Claims) error {
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := ks.Keys[kid]
+		if !ok {
+			return nil, ErrUnknownSigningKey
+		}
+		// Never let the token header pick a different algorithm than the key was configured with
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %q", token.Method.Alg())
+		}
+		return key.PublicKey, nil
+	})
+	return err
+}
+
+// JWKS returns the public part of every asymmetric key in the set.
+// Shared HMAC secrets are never published.
+func (ks *KeySet) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range ks.Keys {
+		switch pub := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA",
+				Kid: key.ID,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case *ecdsa.PublicKey:
+			size := (pub.Curve.Params().BitSize + 7) / 8
+			set.Keys = append(set.Keys, JWK{
+				Kty: "EC",
+				Kid: key.ID,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				Crv: pub.Curve.Params().Name,
+				X:   base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, size))),
+				Y:   base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, size))),
+			})
+		}
+	}
+	return set
+}
+
+// NewSigningKey builds a key for the given algorithm from PEM data.
+// HMAC algorithms take the raw secret instead of PEM. For RSA and ECDSA either a
+// private key (sign and verify) or a public key (verify only) can be given.
+func NewSigningKey(kid, alg string, data []byte) (*SigningKey, error) {
+	method := jwt.GetSigningMethod(alg)
+	if method == nil {
+		return nil, fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+
+	key := &SigningKey{ID: kid, Method: method}
+	switch method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if len(data) < 32 {
+			return nil, fmt.Errorf("HMAC secret for key %q must be at least 32 bytes", kid)
+		}
+		key.PrivateKey = data
+		key.PublicKey = data
+	case *jwt.SigningMethodRSA:
+		if private, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+			key.PrivateKey = private
+			key.PublicKey = &private.PublicKey
+		} else if public, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+			key.PublicKey = public
+		} else {
+			return nil, fmt.Errorf("key %q is not a valid RSA PEM key", kid)
+		}
+	case *jwt.SigningMethodECDSA:
+		if private, err := jwt.ParseECPrivateKeyFromPEM(data); err == nil {
+			key.PrivateKey = private
+			key.PublicKey = &private.PublicKey
+		} else if public, err := jwt.ParseECPublicKeyFromPEM(data); err == nil {
+			key.PublicKey = public
+		} else {
+			return nil, fmt.Errorf("key %q is not a valid EC PEM key", kid)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+	return key, nil
+}
+
+// PublicKey converts an RSA or EC JSON Web Key into a public key usable with jwt-go
+func (k JWK) PublicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+// Cheap parameters keep the tests fast; only the comparison logic is under test
+var testArgon2 = Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestPasswordHasherVerify(t *testing.T) {
+	argon2Hasher := &PasswordHasher{Algorithm: HashArgon2id, Argon2: testArgon2, BcryptCost: 4}
+	bcryptHasher := &PasswordHasher{Algorithm: HashBcrypt, Argon2: testArgon2, BcryptCost: 4}
+
+	argon2Hash, err := argon2Hasher.Hash("Correct-Horse-9")
+	if err != nil {
+		t.Fatalf("argon2id hash: %v", err)
+	}
+	bcryptHash, err := bcryptHasher.Hash("Correct-Horse-9")
+	if err != nil {
+		t.Fatalf("bcrypt hash: %v", err)
+	}
+
+	stronger := testArgon2
+	stronger.Iterations = 2
+
+	tests := []struct {
+		name            string
+		hasher          *PasswordHasher
+		encoded         string
+		password        string
+		wantOK          bool
+		wantNeedsRehash bool
+		wantErr         error
+	}{
+		{"argon2id match", argon2Hasher, argon2Hash, "Correct-Horse-9", true, false, nil},
+		{"argon2id mismatch", argon2Hasher, argon2Hash, "correct-horse-9", false, false, nil},
+		{"argon2id with stronger parameters configured", &PasswordHasher{Algorithm: HashArgon2id, Argon2: stronger}, argon2Hash, "Correct-Horse-9", true, true, nil},
+		{"argon2id while bcrypt is configured", bcryptHasher, argon2Hash, "Correct-Horse-9", true, true, nil},
+		{"bcrypt match", bcryptHasher, bcryptHash, "Correct-Horse-9", true, false, nil},
+		{"bcrypt mismatch", bcryptHasher, bcryptHash, "Correct-Horse-8", false, false, nil},
+		{"bcrypt with higher cost configured", &PasswordHasher{Algorithm: HashBcrypt, BcryptCost: 5}, bcryptHash, "Correct-Horse-9", true, true, nil},
+		{"bcrypt while argon2id is configured", argon2Hasher, bcryptHash, "Correct-Horse-9", true, true, nil},
+		{"unknown format", argon2Hasher, "plaintext", "plaintext", false, false, ErrUnknownPasswordHash},
+		{"argon2id without iterations", argon2Hasher, strings.Replace(argon2Hash, ",t=1,", ",t=0,", 1), "Correct-Horse-9", false, false, ErrUnknownPasswordHash},
+		{"argon2id without lanes", argon2Hasher, strings.Replace(argon2Hash, ",p=1$", ",p=0$", 1), "Correct-Horse-9", false, false, ErrUnknownPasswordHash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, needsRehash, err := tt.hasher.Verify(tt.encoded, tt.password)
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if ok != tt.wantOK || needsRehash != tt.wantNeedsRehash {
+				t.Errorf("ok, needsRehash = %v, %v, want %v, %v", ok, needsRehash, tt.wantOK, tt.wantNeedsRehash)
+			}
+		})
+	}
+}
+
+func TestPasswordHasherArgon2Format(t *testing.T) {
+	hasher := &PasswordHasher{Algorithm: HashArgon2id, Argon2: testArgon2}
+	encoded, err := hasher.Hash("Correct-Horse-9")
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+
+	if !strings.HasPrefix(encoded, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Errorf("unexpected PHC string %q", encoded)
+	}
+	other, _ := hasher.Hash("Correct-Horse-9")
+	if encoded == other {
+		t.Error("two hashes of the same password share a salt")
+	}
+}
@@ -0,0 +1,120 @@
+package utils
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed common_passwords.txt
+var bundledCommonPasswords string
+
+// PasswordPolicy decides whether a new password is strong enough
+type PasswordPolicy struct {
+	MinLength  int
+	MaxLength  int // In characters
+	MaxBytes   int // In bytes of UTF-8, 0 for no limit; bcrypt only looks at the first 72
+	MinClasses int // Distinct character classes: lowercase, uppercase, digits, symbols
+	common     map[string]struct{}
+}
+
+// PasswordPolicyError lists every rule a rejected password broke
+type PasswordPolicyError struct {
+	Problems []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password does not meet the requirements: " + strings.Join(e.Problems, "; ")
+}
+
+// NewPasswordPolicy creates a policy that rejects the bundled common passwords and the given extra ones
+func NewPasswordPolicy(minLength, maxLength, minClasses int, blocklist []string) *PasswordPolicy {
+	p := &PasswordPolicy{MinLength: minLength, MaxLength: maxLength, MinClasses: minClasses, common: map[string]struct{}{}}
+	for _, list := range [][]string{strings.Split(bundledCommonPasswords, "\n"), blocklist} {
+		for _, line := range list {
+			line = strings.ToLower(strings.TrimSpace(line))
+			if line != "" && !strings.HasPrefix(line, "#") {
+				p.common[line] = struct{}{}
+			}
+		}
+	}
+	return p
+}
+
+// Validate checks a new password. personal holds values the password must not
+// contain, such as the username and email address.
+func (p *PasswordPolicy) Validate(password string, personal ...string) error {
+	var problems []string
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		problems = append(problems, fmt.Sprintf("must be at least %d characters long", p.MinLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		problems = append(problems, fmt.Sprintf("must be at most %d characters long", p.MaxLength))
+	} else if p.MaxBytes > 0 && len(password) > p.MaxBytes {
+		problems = append(problems, fmt.Sprintf("must be at most %d bytes long, letters outside the English alphabet count as two or more", p.MaxBytes))
+	}
+
+	if classes := characterClasses(password); classes < p.MinClasses {
+		problems = append(problems, fmt.Sprintf("must mix at least %d of lowercase letters, uppercase letters, digits and symbols", p.MinClasses))
+	}
+
+	if p.IsCommon(password) {
+		problems = append(problems, "is too common and appears in lists of breached passwords")
+	}
+
+	lower := strings.ToLower(password)
+	for _, value := range personal {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if at := strings.Index(value, "@"); at >= 0 {
+			value = value[:at]
+		}
+		if len(value) >= 3 && strings.Contains(lower, value) {
+			problems = append(problems, "must not contain your username or email address")
+			break
+		}
+	}
+
+	if len(problems) > 0 {
+		return &PasswordPolicyError{Problems: problems}
+	}
+	return nil
+}
+
+// IsCommon reports whether the password, ignoring case and trailing digits or
+// symbols ("Password123!"), is on the list of common passwords
+func (p *PasswordPolicy) IsCommon(password string) bool {
+	lower := strings.ToLower(password)
+	if _, ok := p.common[lower]; ok {
+		return true
+	}
+
+	base := strings.TrimRightFunc(lower, func(r rune) bool { return !unicode.IsLetter(r) })
+	if len(base) >= 4 {
+		if _, ok := p.common[base]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// characterClasses counts how many of lowercase, uppercase, digits and symbols the password uses
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	return lower + upper + digit + symbol
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	policy := NewPasswordPolicy(10, 20, 2, []string{"Library2024"})
+	bcryptPolicy := NewPasswordPolicy(10, 72, 2, nil)
+	bcryptPolicy.MaxBytes = 72
+
+	tests := []struct {
+		name     string
+		policy   *PasswordPolicy
+		password string
+		personal []string
+		// wantProblems are substrings of the expected problems, in order; none means valid
+		wantProblems []string
+	}{
+		{"valid", policy, "Correct-Horse-9", nil, nil},
+		{"too short", policy, "Short-1", nil, []string{"at least 10 characters"}},
+		{"too long", policy, "Correct-Horse-Battery-Staple", nil, []string{"at most 20 characters"}},
+		{"length counts characters, not bytes", policy, "Şifremçokgüçlü9", nil, nil},
+		{"single character class", policy, "correcthorsebattery", nil, []string{"mix at least 2"}},
+		{"common password with suffix", policy, "Password123!", nil, []string{"too common"}},
+		{"blocklisted password", policy, "library2024", nil, []string{"too common"}},
+		{"contains username", policy, "JaneDoe-2024x", []string{"janedoe", "jane@example.com"}, []string{"username or email"}},
+		{"contains email local part", policy, "x-Jane.Doe-1", []string{"someone", "jane.doe@example.com"}, []string{"username or email"}},
+		{"several problems", policy, "qwerty", nil, []string{"at least 10", "mix at least 2", "too common"}},
+		{"within bcrypt byte limit", bcryptPolicy, strings.Repeat("aB3", 24), nil, nil},
+		{"over bcrypt byte limit", bcryptPolicy, strings.Repeat("ş", 40) + "A1", nil, []string{"at most 72 bytes"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate(tt.password, tt.personal...)
+			if len(tt.wantProblems) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			policyErr, ok := err.(*PasswordPolicyError)
+			if !ok {
+				t.Fatalf("got %v, want a *PasswordPolicyError", err)
+			}
+			if len(policyErr.Problems) != len(tt.wantProblems) {
+				t.Fatalf("problems = %q, want %d", policyErr.Problems, len(tt.wantProblems))
+			}
+			for i, want := range tt.wantProblems {
+				if !strings.Contains(policyErr.Problems[i], want) {
+					t.Errorf("problem %d = %q, want it to mention %q", i, policyErr.Problems[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyIsCommon(t *testing.T) {
+	policy := NewPasswordPolicy(10, 128, 2, []string{"# comment", " Kutuphane42 ", ""})
+
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{"password", true},
+		{"PASSWORD", true},
+		{"Password123!", true},
+		{"sifre2024", true},
+		{"kutuphane42", true},
+		{"# comment", false},
+		{"abc1", false},
+		{"Correct-Horse-9", false},
+		{"123!", false},
+	}
+
+	for _, tt := range tests {
+		if got := policy.IsCommon(tt.password); got != tt.want {
+			t.Errorf("IsCommon(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
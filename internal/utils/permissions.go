@@ -0,0 +1,53 @@
+package utils
+
+// Permission names checked by middlewares.RequirePermission
+const (
+	PermBooksRead       = "books:read"
+	PermBooksWrite      = "books:write"
+	PermAuthorsRead     = "authors:read"
+	PermAuthorsWrite    = "authors:write"
+	PermReviewsRead     = "reviews:read"
+	PermReviewsWrite    = "reviews:write"    // Write and manage one's own reviews
+	PermReviewsModerate = "reviews:moderate" // Edit or remove anybody's reviews
+	PermUsersRead       = "users:read"
+	PermUsersWrite      = "users:write"
+	PermAccountManage   = "account:manage" // Manage one's own account from /me and the self-service auth routes
+)
+
+// Built-in role names
+const (
+	RoleAdmin     = "admin"
+	RoleLibrarian = "librarian"
+	RoleModerator = "moderator"
+	RoleUser      = "user"
+)
+
+// PermissionDescriptions lists every known permission
+var PermissionDescriptions = map[string]string{
+	PermBooksRead:       "List and view books",
+	PermBooksWrite:      "Create, update and delete books",
+	PermAuthorsRead:     "List and view authors",
+	PermAuthorsWrite:    "Create, update and delete authors",
+	PermReviewsRead:     "List and view reviews",
+	PermReviewsWrite:    "Write reviews and manage one's own reviews",
+	PermReviewsModerate: "Edit and delete any review",
+	PermUsersRead:       "List and view user accounts",
+	PermUsersWrite:      "Manage user accounts, roles and tokens",
+	PermAccountManage:   "View and manage one's own profile, password, sessions, API keys and two-factor settings",
+}
+
+// DefaultRolePermissions is the permission set each built-in role is seeded with.
+// The admin role always receives every known permission.
+var DefaultRolePermissions = map[string][]string{
+	RoleLibrarian: {PermBooksRead, PermBooksWrite, PermAuthorsRead, PermAuthorsWrite, PermReviewsRead, PermReviewsWrite, PermAccountManage},
+	RoleModerator: {PermBooksRead, PermAuthorsRead, PermReviewsRead, PermReviewsWrite, PermReviewsModerate, PermAccountManage},
+	RoleUser:      {PermBooksRead, PermAuthorsRead, PermReviewsRead, PermReviewsWrite, PermAccountManage},
+}
+
+// RoleDescriptions describes the built-in roles
+var RoleDescriptions = map[string]string{
+	RoleAdmin:     "Full access",
+	RoleLibrarian: "Manages the catalogue of books and authors",
+	RoleModerator: "Moderates reviews",
+	RoleUser:      "Reads the catalogue and writes reviews",
+}
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 test key of RFC 6238 appendix B, "12345678901234567890", in base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTOTPRFC6238Vectors(t *testing.T) {
+	// The RFC lists 8 digit codes; 6 digit codes are their last six digits
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+
+	for unix, code := range vectors {
+		step, ok := ValidateTOTP(rfc6238Secret, code, time.Unix(unix, 0))
+		if !ok || step != unix/totpPeriod {
+			t.Errorf("code %s at %d: step %d, ok %v; want step %d", code, unix, step, ok, unix/totpPeriod)
+		}
+	}
+}
+
+func TestValidateTOTPClockDrift(t *testing.T) {
+	issued := time.Unix(1111111111, 0)
+	const code = "050471"
+
+	// One step of drift either way is accepted and reports the step the code was issued in
+	for _, drift := range []time.Duration{-totpPeriod * time.Second, 0, totpPeriod * time.Second} {
+		if step, ok := ValidateTOTP(rfc6238Secret, code, issued.Add(drift)); !ok || step != issued.Unix()/totpPeriod {
+			t.Errorf("drift %v: step %d, ok %v", drift, step, ok)
+		}
+	}
+	if _, ok := ValidateTOTP(rfc6238Secret, code, issued.Add(2*totpPeriod*time.Second)); ok {
+		t.Error("a code two steps old was accepted")
+	}
+}
+
+func TestValidateTOTPRejectsMalformedInput(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	if _, ok := ValidateTOTP(strings.ToLower(rfc6238Secret), "050471", now); !ok {
+		t.Error("a lowercase secret was rejected")
+	}
+	if _, ok := ValidateTOTP(rfc6238Secret, "050472", now); ok {
+		t.Error("a wrong code was accepted")
+	}
+	if _, ok := ValidateTOTP(rfc6238Secret, "50471", now); ok {
+		t.Error("a five digit code was accepted")
+	}
+	if _, ok := ValidateTOTP("not base32!", "050471", now); ok {
+		t.Error("a code was accepted for an invalid secret")
+	}
+}
+
+func TestGenerateTOTPSecretValidates(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret: %v", err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil || len(key) != 20 {
+		t.Fatalf("secret %q does not decode to 20 bytes: %v", secret, err)
+	}
+
+	now := time.Now()
+	if _, ok := ValidateTOTP(secret, totpCode(key, now.Unix()/totpPeriod), now); !ok {
+		t.Error("the current code of a new secret was rejected")
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := TOTPProvisioningURI("Mental Arts", "jane@example.com", rfc6238Secret)
+
+	if !strings.HasPrefix(uri, "otpauth://totp/Mental%20Arts:jane@example.com?") {
+		t.Errorf("URI %q does not start with the escaped label", uri)
+	}
+	if strings.Contains(uri, "+") {
+		t.Errorf("URI %q encodes spaces as +", uri)
+	}
+	if !strings.Contains(uri, "secret="+rfc6238Secret) || !strings.Contains(uri, "issuer=Mental%20Arts") {
+		t.Errorf("URI %q lacks the secret or issuer", uri)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	code, err := GenerateRecoveryCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(code) != 11 || code[5] != '-' {
+		t.Fatalf("recovery code %q is not of the form xxxxx-xxxxx", code)
+	}
+	// Users may type the code with capitals, spaces or without the dash
+	typed := strings.ToUpper(code[:5]) + " " + code[6:]
+	if NormalizeRecoveryCode(typed) != NormalizeRecoveryCode(code) {
+		t.Errorf("%q and %q normalise differently", typed, code)
+	}
+}
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDescribeUserAgent(t *testing.T) {
+	labels := map[string]string{
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36":                         "Chrome on Windows",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.2478.51":       "Edge on Windows",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 OPR/109.0.0.0":     "Opera on macOS",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_4) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Safari/605.1.15":                      "Safari on macOS",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1": "Safari on iPhone",
+		"Mozilla/5.0 (iPad; CPU OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1":          "Safari on iPad",
+		"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Mobile Safari/537.36":                   "Chrome on Android",
+		"Mozilla/5.0 (X11; CrOS x86_64 14541.0.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36":                          "Chrome on ChromeOS",
+		"PostmanRuntime/7.37.3": "Postman",
+		"okhttp/4.12.0":         "Android app",
+		"Go-http-client/1.1":    "Go client",
+		// Only the platform or nothing at all is recognised
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64)": "Windows",
+		"SomeBot/1.0": "Unknown device",
+		"":            "Unknown device",
+	}
+
+	for userAgent, want := range labels {
+		if got := DescribeUserAgent(userAgent); got != want {
+			t.Errorf("DescribeUserAgent(%q) = %q, want %q", userAgent, got, want)
+		}
+	}
+}
+
+func ExampleDescribeUserAgent() {
+	fmt.Println(DescribeUserAgent("Mozilla/5.0 (X11; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0"))
+	fmt.Println(DescribeUserAgent("curl/8.5.0"))
+	// Output:
+	// Firefox on Linux
+	// curl
+}
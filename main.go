@@ -2,12 +2,16 @@ package main
 
 import (
 	"context"
+	"log"
 	"mentalartsapi/config"
+	"mentalartsapi/internal/cli"
 	"mentalartsapi/internal/handlers"
+	"mentalartsapi/internal/mailer"
 	"mentalartsapi/internal/middlewares"
 	"mentalartsapi/internal/repository"
 	"mentalartsapi/internal/services"
 	"mentalartsapi/routes"
+	"os"
 
 	_ "mentalartsapi/docs"
 
@@ -35,10 +39,31 @@ import (
 // @securityDefinitions.apikey	Bearer
 // @in							header
 // @name						Authorization
+
+// @securityDefinitions.apikey	ApiKey
+// @in							header
+// @name						X-API-Key
 func main() {
+	// Maintenance commands such as create-admin run instead of the server
+	if len(os.Args) > 1 {
+		if err := cli.Run(os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Load configuration from the environment
+	config.LoadAuthConfig()
+	config.LoadJWTKeys()
+	config.LoadMailConfig()
+	config.LoadPasswordConfig()
+	config.LoadOIDCConfig()
+	config.LoadReviewConfig()
+
 	// Connect to the database and migrate models
 	config.ConnectDatabase()
 	config.MigrateDB()
+	config.SeedAdminUser()
 
 	// Create a context for Redis operations
 	ctx := context.Background()
@@ -47,18 +72,43 @@ func main() {
 	bookRepo := repository.NewBookRepository()
 	authorRepo := repository.NewAuthorRepository()
 	reviewRepo := repository.NewReviewRepository()
+	reviewReportRepo := repository.NewReviewReportRepository()
+	reviewReplyRepo := repository.NewReviewReplyRepository()
 	userRepo := repository.NewUserRepository(config.DB)
+	roleRepo := repository.NewRoleRepository(config.DB)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(config.DB)
+	passwordResetRepo := repository.NewPasswordResetRepository(config.DB)
+	sessionRepo := repository.NewSessionRepository(config.DB)
+	apiKeyRepo := repository.NewAPIKeyRepository(config.DB)
+	recoveryCodeRepo := repository.NewRecoveryCodeRepository(config.DB)
 
 	bookService := services.NewBookService(bookRepo, config.Redis, ctx)
 	authorService := services.NewAuthorService(authorRepo, config.Redis, ctx)
-	reviewService := services.NewReviewService(reviewRepo, config.Redis, ctx)
-	authService := services.NewAuthService(*userRepo)
+	reviewFilters := services.NewReviewFilterPipeline(config.Reviews.Filters, reviewRepo, reviewReplyRepo, config.Redis, ctx)
+	reviewService := services.NewReviewService(reviewRepo, reviewFilters, config.Redis, ctx)
+	reviewReportService := services.NewReviewReportService(reviewReportRepo, reviewService)
+	reviewReplyService := services.NewReviewReplyService(reviewReplyRepo, reviewService)
+	loginAttemptService := services.NewLoginAttemptService(config.Redis, ctx)
+	mail := mailer.NewMailer(config.Mail)
+	authService := services.NewAuthService(*userRepo, *roleRepo, *refreshTokenRepo, *passwordResetRepo, *sessionRepo, *apiKeyRepo, mail)
+	userService := services.NewUserService(*userRepo, *roleRepo, authService)
+	apiKeyService := services.NewAPIKeyService(*apiKeyRepo, *userRepo)
+	oidcService := services.NewOIDCService(*userRepo, *roleRepo, authService)
+	twoFactorService := services.NewTwoFactorService(*userRepo, *recoveryCodeRepo, authService)
 
 	// Initialize handlers
 	bookHandler := handlers.NewBookHandler(bookService)
 	authorHandler := handlers.NewAuthorHandler(authorService)
-	reviewHandler := handlers.NewReviewHandler(reviewService)
-	authHandler := handlers.NewAuthHandler(authService)
+	reviewHandler := handlers.NewReviewHandler(reviewService, reviewReplyService)
+	authHandler := handlers.NewAuthHandler(authService, loginAttemptService, twoFactorService)
+	adminHandler := handlers.NewAdminHandler(authService, userService, loginAttemptService, twoFactorService)
+	meHandler := handlers.NewMeHandler(userService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	oidcHandler := handlers.NewOIDCHandler(oidcService, twoFactorService)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService)
+	sessionHandler := handlers.NewSessionHandler(authService)
+	reviewReportHandler := handlers.NewReviewReportHandler(reviewReportService)
+	reviewReplyHandler := handlers.NewReviewReplyHandler(reviewReplyService)
 
 	// Set up the router
 	r := gin.Default()
@@ -72,7 +122,7 @@ func main() {
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Set up routes (using a separate routes.go file)
-	routes.SetupRoutes(r, bookHandler, authorHandler, reviewHandler, authHandler)
+	routes.SetupRoutes(r, bookHandler, authorHandler, reviewHandler, authHandler, adminHandler, meHandler, apiKeyHandler, oidcHandler, twoFactorHandler, sessionHandler, reviewReportHandler, reviewReplyHandler)
 
 	// Start the server
 	r.Run(":8000")
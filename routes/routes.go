@@ -3,11 +3,20 @@ package routes
 import (
 	"mentalartsapi/internal/handlers"
 	"mentalartsapi/internal/middlewares"
+	"mentalartsapi/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(router *gin.Engine, bookHandler *handlers.BookHandler, authorHandler *handlers.AuthorHandler, reviewHandler *handlers.ReviewHandler, authHandler *handlers.AuthHandler) {
+func SetupRoutes(router *gin.Engine, bookHandler *handlers.BookHandler, authorHandler *handlers.AuthorHandler, reviewHandler *handlers.ReviewHandler, authHandler *handlers.AuthHandler, adminHandler *handlers.AdminHandler, meHandler *handlers.MeHandler, apiKeyHandler *handlers.APIKeyHandler, oidcHandler *handlers.OIDCHandler, twoFactorHandler *handlers.TwoFactorHandler, sessionHandler *handlers.SessionHandler, reviewReportHandler *handlers.ReviewReportHandler, reviewReplyHandler *handlers.ReviewReplyHandler) {
+	// Shorthand for declaring the permission a route needs
+	can := middlewares.RequirePermission
+	// Routes acting on the caller's own account refuse API keys
+	interactive := middlewares.RequireInteractiveLogin()
+
+	// Public keys for verifying access tokens
+	router.GET("/.well-known/jwks.json", authHandler.GetJWKS)
+
 	v1 := router.Group("/api/v1")
 	{
 		// Public routes for authentication
@@ -15,39 +24,116 @@ func SetupRoutes(router *gin.Engine, bookHandler *handlers.BookHandler, authorHa
 		{
 			authRoutes.POST("/register", authHandler.RegisterUser)
 			authRoutes.POST("/login", authHandler.LoginUser)
+			authRoutes.POST("/login/2fa", authHandler.LoginTwoFactor)
 			authRoutes.POST("/refresh-token", authHandler.RefreshToken)
+			authRoutes.POST("/logout", authHandler.Logout)
+			authRoutes.GET("/verify-email", authHandler.VerifyEmail)
+			authRoutes.POST("/verify-email", authHandler.VerifyEmail)
+			authRoutes.POST("/resend-verification", authHandler.ResendVerification)
+			authRoutes.POST("/forgot-password", authHandler.ForgotPassword)
+			authRoutes.POST("/reset-password", authHandler.ResetPassword)
+			authRoutes.GET("/oidc/login", oidcHandler.StartLogin)
+			authRoutes.GET("/oidc/callback", oidcHandler.Callback)
+
+			// Self-service routes act on the caller's own account
+			authRoutes.POST("/logout-all", middlewares.JWTAuthMiddleware(), interactive, can(utils.PermAccountManage), authHandler.LogoutAll)
+			authRoutes.POST("/change-password", middlewares.JWTAuthMiddleware(), interactive, can(utils.PermAccountManage), authHandler.ChangePassword)
 		}
 
-		// Protected routes with JWT authentication
-		v1.Use(middlewares.JWTAuthMiddleware()) // Protect these routes with JWT Auth Middleware
+		// Protected routes, authenticated with an X-API-Key header or a Bearer token
+		v1.Use(middlewares.APIKeyAuthMiddleware(apiKeyHandler.Service), middlewares.JWTAuthMiddleware())
+
+		// Current user routes act on the caller's own account
+		me := v1.Group("/me", interactive, can(utils.PermAccountManage))
+		{
+			me.GET("", meHandler.GetProfile)
+			me.PATCH("", meHandler.UpdateProfile)
+			me.GET("/api-keys", apiKeyHandler.GetAPIKeys)
+			me.POST("/api-keys", apiKeyHandler.CreateAPIKey)
+			me.DELETE("/api-keys/:id", apiKeyHandler.RevokeAPIKey)
+			me.GET("/reviews", reviewHandler.GetMyReviews)
+			me.GET("/sessions", sessionHandler.GetMySessions)
+			me.DELETE("/sessions/:id", sessionHandler.RevokeMySession)
+			me.GET("/2fa", twoFactorHandler.GetStatus)
+			me.POST("/2fa/setup", twoFactorHandler.Setup)
+			me.POST("/2fa/enable", twoFactorHandler.Enable)
+			me.POST("/2fa/disable", twoFactorHandler.Disable)
+			me.POST("/2fa/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes)
+		}
 
-		// Book routes (Admin or Author can perform POST, PUT, DELETE)
+		// Book routes
 		books := v1.Group("/books")
 		{
-			books.GET("/", bookHandler.GetBooks)
-			books.GET("/:id", bookHandler.GetBook)
-			books.POST("/", middlewares.AdminOnly(), bookHandler.CreateBook)      // Only Admin can POST
-			books.PUT("/:id", middlewares.AdminOnly(), bookHandler.UpdateBook)    // Only Admin can PUT
-			books.DELETE("/:id", middlewares.AdminOnly(), bookHandler.DeleteBook) // Only Admin can DELETE
-			books.GET("/:id/reviews", reviewHandler.GetReviewsForBook)
-			books.POST("/:id/reviews", reviewHandler.CreateReview)
+			books.GET("/", can(utils.PermBooksRead), bookHandler.GetBooks)
+			books.GET("/:id", can(utils.PermBooksRead), bookHandler.GetBook)
+			books.POST("/", can(utils.PermBooksWrite), bookHandler.CreateBook)
+			books.PUT("/:id", can(utils.PermBooksWrite), bookHandler.UpdateBook)
+			books.DELETE("/:id", can(utils.PermBooksWrite), bookHandler.DeleteBook)
+			books.GET("/:id/reviews", can(utils.PermReviewsRead), reviewHandler.GetReviewsForBook)
+			books.POST("/:id/reviews", can(utils.PermReviewsWrite), reviewHandler.CreateReview)
+			books.PUT("/:id/reviews/mine", can(utils.PermReviewsWrite), reviewHandler.SaveMyReview)
 		}
 
-		// Author routes (Admin or Author can perform POST, PUT, DELETE)
+		// Author routes
 		authors := v1.Group("/authors")
 		{
-			authors.GET("/", authorHandler.GetAuthors)
-			authors.GET("/:id", authorHandler.GetAuthor)
-			authors.POST("/", middlewares.AdminOnly(), authorHandler.CreateAuthor)      // Only Admin can POST
-			authors.PUT("/:id", middlewares.AdminOnly(), authorHandler.UpdateAuthor)    // Only Admin can PUT
-			authors.DELETE("/:id", middlewares.AdminOnly(), authorHandler.DeleteAuthor) // Only Admin can DELETE
+			authors.GET("/", can(utils.PermAuthorsRead), authorHandler.GetAuthors)
+			authors.GET("/:id", can(utils.PermAuthorsRead), authorHandler.GetAuthor)
+			authors.POST("/", can(utils.PermAuthorsWrite), authorHandler.CreateAuthor)
+			authors.PUT("/:id", can(utils.PermAuthorsWrite), authorHandler.UpdateAuthor)
+			authors.DELETE("/:id", can(utils.PermAuthorsWrite), authorHandler.DeleteAuthor)
 		}
 
-		// Review routes
+		// Review routes. Ownership is checked by the service, reviews:moderate allows changing any review.
 		reviews := v1.Group("/reviews")
 		{
-			reviews.PUT("/:id", middlewares.AdminOnly(), reviewHandler.UpdateReview)    // Only Admin can PUT
-			reviews.DELETE("/:id", middlewares.AdminOnly(), reviewHandler.DeleteReview) // Only Admin can DELETE
+			reviews.GET("/:id", can(utils.PermReviewsRead), reviewHandler.GetReview)
+			reviews.PUT("/:id", can(utils.PermReviewsWrite), reviewHandler.UpdateReview)
+			reviews.DELETE("/:id", can(utils.PermReviewsWrite), reviewHandler.DeleteReview)
+			reviews.PUT("/:id/vote", can(utils.PermReviewsWrite), reviewHandler.VoteReview)
+			reviews.DELETE("/:id/vote", can(utils.PermReviewsWrite), reviewHandler.RemoveVote)
+			reviews.POST("/:id/reports", can(utils.PermReviewsWrite), reviewReportHandler.ReportReview)
+			reviews.GET("/:id/replies", can(utils.PermReviewsRead), reviewReplyHandler.GetReplies)
+			reviews.POST("/:id/replies", can(utils.PermReviewsWrite), reviewReplyHandler.CreateReply)
+		}
+
+		// Reply routes, with the same ownership rules as reviews
+		replies := v1.Group("/replies")
+		{
+			replies.PUT("/:id", can(utils.PermReviewsWrite), reviewReplyHandler.UpdateReply)
+			replies.DELETE("/:id", can(utils.PermReviewsWrite), reviewReplyHandler.DeleteReply)
+		}
+
+		// Public user profile routes
+		users := v1.Group("/users")
+		{
+			users.GET("/:id/reviews", can(utils.PermReviewsRead), reviewHandler.GetUserReviews)
+		}
+
+		// Admin routes
+		admin := v1.Group("/admin")
+		{
+			admin.GET("/users", can(utils.PermUsersRead), adminHandler.ListUsers)
+			admin.GET("/users/:id", can(utils.PermUsersRead), adminHandler.GetUser)
+			admin.PUT("/users/:id/role", can(utils.PermUsersWrite), adminHandler.UpdateUserRole)
+			admin.POST("/users/:id/deactivate", can(utils.PermUsersWrite), adminHandler.DeactivateUser)
+			admin.POST("/users/:id/reactivate", can(utils.PermUsersWrite), adminHandler.ReactivateUser)
+			admin.DELETE("/users/:id", can(utils.PermUsersWrite), adminHandler.DeleteUser)
+			admin.POST("/users/:id/revoke-tokens", can(utils.PermUsersWrite), adminHandler.RevokeUserTokens)
+			admin.POST("/users/:id/unlock", can(utils.PermUsersWrite), adminHandler.UnlockUser)
+			admin.GET("/users/:id/sessions", can(utils.PermUsersRead), sessionHandler.GetUserSessions)
+			admin.DELETE("/users/:id/sessions/:session_id", can(utils.PermUsersWrite), sessionHandler.RevokeUserSession)
+			admin.POST("/users/:id/2fa/reset", can(utils.PermUsersWrite), adminHandler.ResetTwoFactor)
+			admin.GET("/reviews/queue", can(utils.PermReviewsModerate), reviewHandler.GetModerationQueue)
+			admin.POST("/reviews/:id/approve", can(utils.PermReviewsModerate), reviewHandler.ApproveReview)
+			admin.POST("/reviews/:id/reject", can(utils.PermReviewsModerate), reviewHandler.RejectReview)
+			admin.POST("/reviews/:id/hide", can(utils.PermReviewsModerate), reviewHandler.HideReview)
+			admin.GET("/reviews/reports", can(utils.PermReviewsModerate), reviewReportHandler.GetReportedReviews)
+			admin.POST("/reviews/:id/reports/resolve", can(utils.PermReviewsModerate), reviewReportHandler.ResolveReports)
+			admin.GET("/replies/queue", can(utils.PermReviewsModerate), reviewReplyHandler.GetModerationQueue)
+			admin.POST("/replies/:id/approve", can(utils.PermReviewsModerate), reviewReplyHandler.ApproveReply)
+			admin.POST("/replies/:id/reject", can(utils.PermReviewsModerate), reviewReplyHandler.RejectReply)
+			admin.POST("/replies/:id/hide", can(utils.PermReviewsModerate), reviewReplyHandler.HideReply)
 		}
 
 	}